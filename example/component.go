@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"runtime"
 	"time"
@@ -22,8 +23,20 @@ func init() {
 	})
 }
 
+const (
+	httpAPIServerServiceID frame.ServiceID = "HTTPAPIServer"
+)
+
 type HTTPAPIServerComponentKW struct {
-	ServerAddr string `json:"server_addr"`
+	ServerAddr        string `json:"server_addr" required:"true"`
+	HandlerWorkersNum int    `json:"handler_workers_num" default:"100"`
+}
+
+func (t *HTTPAPIServerComponentKW) Validate() error {
+	if t.HandlerWorkersNum <= 0 {
+		return errors.New("handler_workers_num must be greater than 0")
+	}
+	return nil
 }
 
 type HTTPAPIServerComponent struct {
@@ -32,24 +45,29 @@ type HTTPAPIServerComponent struct {
 
 func (t *HTTPAPIServerComponent) Initialize(kw frame.IComponentKW) error {
 	kwArgs := kw.(*HTTPAPIServerComponentKW)
-
-	frame.SubscribeEventMessage(frame.EventAPPStarted, t.GetID(), func(args ...interface{}) {
-		getGlobalLoggerInstance().Info("Test EventAPPStarted for HTTPAPIServerComponent")
-	})
 	getGlobalLoggerInstance().InfoF("HTTPAPIServer Initialize KWArgs: %v", kwArgs)
-	if !frame.RegisterConfigCallback(frame.ConfigCallbackTypeUpdate, GetConfigHandler(), onUpdateConfigEvent) {
-		return errors.New("unable to register configuration callback function")
+	if err := frame.PublishService(t, httpAPIServerServiceID, t); err != nil {
+		return err
 	}
 
-	return nil
+	// The subscriptions made under the component id are kept when the component restarts
+	if _, err := GetSidecarConfig().Subscribe(t.GetID(), onUpdateConfig); err != nil {
+		return err
+	}
+	return frame.SubscribeEventMessage(frame.EventAPPStarted, t.GetID(), func(args ...interface{}) {
+		event := args[0].(frame.APPStartedEvent)
+		getGlobalLoggerInstance().InfoF("Test EventAPPStarted for HTTPAPIServerComponent, StartupDuration: %v",
+			event.StartupDuration)
+	})
 }
 
-func (t *HTTPAPIServerComponent) Start() error {
-	return nil
+func (t *HTTPAPIServerComponent) CheckHealth(ctx context.Context) frame.HealthStatus {
+	return frame.HealthStatus{State: frame.HealthStateHealthy}
 }
 
 type MonitorComponentKW struct {
-	ServerAddr string `json:"server_addr"`
+	ServerAddr string `json:"server_addr" required:"true"`
+	AccessKey  string `json:"access_key"`
 }
 
 type MonitorComponent struct {
@@ -59,10 +77,12 @@ type MonitorComponent struct {
 func (t *MonitorComponent) Initialize(kw frame.IComponentKW) error {
 	kwArgs := kw.(*MonitorComponentKW)
 	getGlobalLoggerInstance().InfoF("MonitorComponent Initialize KWArgs: %v", kwArgs)
-
-	frame.SubscribeEventMessage(frame.EventAPPStarted, t.GetID(), func(args ...interface{}) {
-		getGlobalLoggerInstance().Info("Test EventAPPStarted for MonitorComponent")
-	})
+	frame.RequireService[*HTTPAPIServerComponent](t, httpAPIServerServiceID)
+	if _, err := frame.Subscribe(t.GetID(), func(_ context.Context, e frame.APPStartedEvent) {
+		getGlobalLoggerInstance().InfoF("Test APPStartedEvent for MonitorComponent, AppID: %v", e.AppID)
+	}, frame.WithAsyncDelivery(1, frame.OverflowDropOldest), frame.WithOnce()); err != nil {
+		return err
+	}
 
 	go func() {
 		for {
@@ -79,6 +99,6 @@ func (t *MonitorComponent) Initialize(kw frame.IComponentKW) error {
 	return nil
 }
 
-func onUpdateConfigEvent() {
-	getGlobalLoggerInstance().DebugF("Updated the configuration, addr: %s", GetConfig().Addr)
+func onUpdateConfig(old, new *SidecarConfig) {
+	getGlobalLoggerInstance().DebugF("Updated the configuration, addr: %s", new.Addr)
 }
@@ -1,66 +1,36 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+
 	"github.com/akley-MK4/micro-app/frame"
 )
 
 var (
-	configHandler = &ConfigHandler{
-		cfg: &SidecarConfig{},
-	}
-
-	regConfigInfoMap = map[string]frame.ConfigRegInfo{
-		"http_api_routes": {
-			Suffix: "json", MustLoad: false, NewConfigHandlerFunc: func() frame.IConfigHandler {
-				return GetConfigHandler()
-			},
-			RetryWatchIntervalSec: 5,
-		},
-	}
+	sidecarConfig *frame.TypedConfig[SidecarConfig]
 )
 
 func registerConfigs() {
-	for key, info := range regConfigInfoMap {
-		info.Key = key
-		frame.RegisterConfigInfo(info)
-	}
+	sidecarConfig = frame.RegisterTypedConfig[SidecarConfig](frame.ConfigRegInfo{
+		Key: "http_api_routes", Suffix: "json", MustLoad: false, RetryWatchIntervalSec: 5,
+	})
 }
 
-type ConfigHandler struct {
-	cfg *SidecarConfig
-}
-
-func (t *ConfigHandler) OnUpdate() {
-
+type SidecarConfig struct {
+	Addr string `json:"addr,omitempty"`
 }
 
-func (t *ConfigHandler) EncodeConfig(data []byte) error {
-	v := &SidecarConfig{}
-	if err := json.Unmarshal(data, v); err != nil {
-		return err
+func (t *SidecarConfig) Validate() error {
+	if t.Addr == "" {
+		return errors.New("addr is empty")
 	}
-
-	t.cfg = v
 	return nil
 }
 
-func (t *ConfigHandler) GetConfigData() ([]byte, error) {
-	if t.cfg == nil {
-		return []byte{}, nil
-	}
-
-	return json.Marshal(t.cfg)
-}
-
-type SidecarConfig struct {
-	Addr string `json:"addr,omitempty"`
-}
-
-func GetConfigHandler() *ConfigHandler {
-	return configHandler
+func GetSidecarConfig() *frame.TypedConfig[SidecarConfig] {
+	return sidecarConfig
 }
 
 func GetConfig() *SidecarConfig {
-	return configHandler.cfg
+	return sidecarConfig.Get()
 }
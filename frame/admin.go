@@ -0,0 +1,270 @@
+package frame
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAdminAddr        = "127.0.0.1:9090"
+	adminReadHeaderTimeout  = 5 * time.Second
+	adminRestartTimeoutSec  = 30
+	adminMaxRequestBodySize = 1 << 20
+)
+
+const (
+	AdminEndpointComponents       = "components"
+	AdminEndpointConfigs          = "configs"
+	AdminEndpointMemory           = "memory"
+	AdminEndpointGC               = "gc"
+	AdminEndpointProcess          = "process"
+	AdminEndpointHealth           = "health"
+	AdminEndpointForceGC          = "force_gc"
+	AdminEndpointMemoryLimit      = "memory_limit"
+	AdminEndpointLogLevel         = "log_level"
+	AdminEndpointRestartComponent = "restart_component"
+)
+
+// AdminConfig configures the built-in admin server. Endpoints lists the enabled endpoints, all are enabled if it is empty.
+// When Token is set, every request must carry the header "Authorization: Bearer <token>".
+type AdminConfig struct {
+	Enable    bool     `json:"enable"`
+	Addr      string   `json:"addr"`
+	Token     string   `json:"token"`
+	Endpoints []string `json:"endpoints"`
+}
+
+type adminRoute struct {
+	endpoint string
+	pattern  string
+	handler  http.HandlerFunc
+}
+
+var (
+	adminServerInst = &adminServer{}
+)
+
+type adminServer struct {
+	server *http.Server
+}
+
+func (t *adminServer) start(cfg AdminConfig) error {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultAdminAddr
+	}
+
+	enabledMap := make(map[string]bool)
+	for _, endpoint := range cfg.Endpoints {
+		enabledMap[endpoint] = true
+	}
+
+	mux := http.NewServeMux()
+	for _, route := range t.getRoutes() {
+		if len(enabledMap) > 0 && !enabledMap[route.endpoint] {
+			continue
+		}
+		mux.HandleFunc(route.pattern, route.handler)
+	}
+
+	listener, listenErr := net.Listen("tcp", addr)
+	if listenErr != nil {
+		return listenErr
+	}
+
+	t.server = &http.Server{
+		Handler:           t.withAuth(cfg.Token, mux),
+		ReadHeaderTimeout: adminReadHeaderTimeout,
+	}
+	go func() {
+		if err := t.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			getLoggerInst().WarningF("The admin server has quit, %v", err)
+		}
+	}()
+
+	getLoggerInst().InfoF("The admin server is listening on %v", listener.Addr())
+	return nil
+}
+
+func (t *adminServer) stop(ctx context.Context) error {
+	if t.server == nil {
+		return nil
+	}
+	return t.server.Shutdown(ctx)
+}
+
+func (t *adminServer) getRoutes() []adminRoute {
+	return []adminRoute{
+		{AdminEndpointComponents, "GET /admin/components", t.handleComponents},
+		{AdminEndpointConfigs, "GET /admin/configs", t.handleConfigs},
+		{AdminEndpointMemory, "GET /admin/memory", t.handleMemory},
+		{AdminEndpointGC, "GET /admin/gc", t.handleGC},
+		{AdminEndpointProcess, "GET /admin/process", t.handleProcess},
+		{AdminEndpointHealth, "GET /admin/health", t.handleHealth},
+		{AdminEndpointHealth, "GET /admin/ready", t.handleReady},
+		{AdminEndpointForceGC, "POST /admin/gc/force", t.handleForceGC},
+		{AdminEndpointMemoryLimit, "POST /admin/memory/limit", t.handleMemoryLimit},
+		{AdminEndpointLogLevel, "POST /admin/log/level", t.handleLogLevel},
+		{AdminEndpointRestartComponent, "POST /admin/components/restart", t.handleRestartComponent},
+	}
+}
+
+func (t *adminServer) withAuth(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *adminServer) handleComponents(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, GetComponentMgr().GetComponentStatusInfoList())
+}
+
+func (t *adminServer) handleConfigs(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, GetConfigWatcherMgr().GetConfigWatcherListInfo())
+}
+
+func (t *adminServer) handleMemory(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, map[string]*MemorySnapshot{
+		"initial": GetInitialMemorySnapshot(),
+		"current": NewMemorySnapshot(),
+	})
+}
+
+func (t *adminServer) handleGC(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, GetGCSettings())
+}
+
+func (t *adminServer) handleProcess(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{
+		"app_id":       GetCurrentAppID(),
+		"pid":          os.Getpid(),
+		"process_type": GetCurrentProcessType(),
+		"started":      IsAppStarted(),
+	})
+}
+
+func (t *adminServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	info := GetHealthMgr().GetAppHealthInfo()
+	code := http.StatusOK
+	if info.State == HealthStateUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeAdminJSON(w, code, info)
+}
+
+func (t *adminServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	reasons := getNotReadyReasons()
+	code := http.StatusOK
+	if len(reasons) > 0 {
+		code = http.StatusServiceUnavailable
+	}
+	writeAdminJSON(w, code, map[string]interface{}{"ready": len(reasons) == 0, "reasons": reasons})
+}
+
+func (t *adminServer) handleForceGC(w http.ResponseWriter, r *http.Request) {
+	duration := forceGC("admin")
+	getLoggerInst().Info("Forced GC by the admin server")
+	writeAdminJSON(w, http.StatusOK, map[string]interface{}{"duration_ms": duration.Milliseconds()})
+}
+
+func (t *adminServer) handleMemoryLimit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		LimitBytes int64 `json:"limit_bytes"`
+	}
+	if err := decodeAdminRequest(w, r, &req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.LimitBytes <= 0 {
+		writeAdminError(w, http.StatusBadRequest, errors.New("limit_bytes must be greater than 0"))
+		return
+	}
+
+	UpdateMemoryUsageLimitBytes(req.LimitBytes)
+	writeAdminJSON(w, http.StatusOK, GetGCSettings())
+}
+
+func (t *adminServer) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := decodeAdminRequest(w, r, &req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !getLoggerInst().SetLevelByDesc(req.Level) {
+		writeAdminError(w, http.StatusBadRequest, fmt.Errorf("unable to set the log level %v", req.Level))
+		return
+	}
+	getLoggerInst().InfoF("The log level has been set to %v by the admin server", req.Level)
+	writeAdminJSON(w, http.StatusOK, map[string]string{"level": req.Level})
+}
+
+func (t *adminServer) handleRestartComponent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID ComponentID `json:"id"`
+	}
+	if err := decodeAdminRequest(w, r, &req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	component, exist := GetComponentMgr().GetComponentByID(req.ID)
+	if !exist {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("component %v dose not exist", req.ID))
+		return
+	}
+
+	// A client that disconnects must not abort the restart halfway and leave the component stopped
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*adminRestartTimeoutSec)
+	defer cancel()
+	if err := GetComponentMgr().RestartComponent(ctx, req.ID); err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err)
+		return
+	}
+	getLoggerInst().InfoF("The component %v has been restarted by the admin server", req.ID)
+	writeAdminJSON(w, http.StatusOK, component.GetStatusInfo())
+}
+
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, adminMaxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body, %v", err)
+	}
+	return nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, code int, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		code = http.StatusInternalServerError
+		data = []byte(fmt.Sprintf(`{"error": %q}`, err.Error()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+func writeAdminError(w http.ResponseWriter, code int, err error) {
+	writeAdminJSON(w, code, map[string]string{"error": strings.TrimSpace(err.Error())})
+}
@@ -0,0 +1,94 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveAdminRequest(handler http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestAdminServerAuth(t *testing.T) {
+	server := &adminServer{}
+	handler := server.withAuth("secret", http.HandlerFunc(server.handleProcess))
+
+	if code := serveAdminRequest(handler, http.MethodGet, "/admin/process", "", nil).Code; code != http.StatusUnauthorized {
+		t.Errorf("got code %d without a token, want 401", code)
+	}
+	header := map[string]string{"Authorization": "Bearer wrong"}
+	if code := serveAdminRequest(handler, http.MethodGet, "/admin/process", "", header).Code; code != http.StatusUnauthorized {
+		t.Errorf("got code %d with a wrong token, want 401", code)
+	}
+	header["Authorization"] = "Bearer secret"
+	if code := serveAdminRequest(handler, http.MethodGet, "/admin/process", "", header).Code; code != http.StatusOK {
+		t.Errorf("got code %d with the token, want 200", code)
+	}
+}
+
+func TestAdminServerRestartComponent(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)})
+	useTestComponentMgr(t, mgr)
+	handler := http.HandlerFunc((&adminServer{}).handleRestartComponent)
+
+	recorder := serveAdminRequest(handler, http.MethodPost, "/admin/components/restart", `{"id": "store"}`, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got code %d, %s", recorder.Code, recorder.Body)
+	}
+	var info struct {
+		ID     ComponentID
+		Status string
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != "store" || info.Status != "Running" {
+		t.Errorf("got %+v, want the running component", info)
+	}
+	checkTestMgrEvents(t, "stop store", "start store")
+
+	// The restart is completed even though the client has gone away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/admin/components/restart", strings.NewReader(`{"id": "store"}`)).WithContext(ctx)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got code %d after the client has gone away, %s", recorder.Code, recorder.Body)
+	}
+	checkTestMgrEvents(t, "stop store", "start store")
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"id": "absent"}`, http.StatusNotFound},
+		{`{"id": "store", "force": true}`, http.StatusBadRequest},
+		{`{"id":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code := serveAdminRequest(handler, http.MethodPost, "/admin/components/restart", tt.body, nil).Code; code != tt.code {
+			t.Errorf("got code %d for %s, want %d", code, tt.body, tt.code)
+		}
+	}
+	checkTestMgrEvents(t)
+}
+
+func TestAdminServerMemoryLimitRejectsInvalidLimits(t *testing.T) {
+	handler := http.HandlerFunc((&adminServer{}).handleMemoryLimit)
+	for _, body := range []string{`{"limit_bytes": 0}`, `{"limit_bytes": -1}`, `{"limit": 1}`} {
+		if code := serveAdminRequest(handler, http.MethodPost, "/admin/memory/limit", body, nil).Code; code != http.StatusBadRequest {
+			t.Errorf("got code %d for %s, want 400", code, body)
+		}
+	}
+}
@@ -1,21 +1,15 @@
 package frame
 
 import (
-	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 )
 
 type ComponentType string
 type ComponentID string
 type ComponentStatus uint16
 
-const (
-	ComponentBaseInitStatus = iota
-	ComponentInitStatus
-	ComponentStartStatus
-	ComponentStopStatus
-)
-
 const (
 	ComponentPriorityLow = iota
 	ComponentPriorityGeneral
@@ -33,14 +27,19 @@ type RegComponentInfo struct {
 	Tpy            ComponentType
 	NewComponent   NewComponent
 	NewComponentKW NewComponentKW
+	// DependsOn lists the component types or component ids that must be started before this component type
+	DependsOn []string
 }
 
 type IComponent interface {
-	baseInitialize(index int, tpy ComponentType) error
+	baseInitialize(index int, tpy ComponentType, id ComponentID) error
+	getBaseComponent() *BaseComponent
 	Initialize(kw IComponentKW) error
 	GetIndex() int
 	GetID() ComponentID
 	GetType() ComponentType
+	GetStatus() ComponentStatus
+	GetStatusInfo() ComponentStatusInfo
 	Start() error
 	Stop() error
 }
@@ -49,16 +48,18 @@ var (
 	regComponentInfoMap = make(map[ComponentType]*RegComponentInfo)
 )
 
-func RegisterComponentInfo(priority int, tpy ComponentType, newComponent NewComponent, newComponentKW NewComponentKW) {
+func RegisterComponentInfo(priority int, tpy ComponentType, newComponent NewComponent, newComponentKW NewComponentKW,
+	dependsOn ...string) {
 	regComponentInfoMap[tpy] = &RegComponentInfo{
 		Priority:       priority,
 		Tpy:            tpy,
 		NewComponent:   newComponent,
 		NewComponentKW: newComponentKW,
+		DependsOn:      dependsOn,
 	}
 }
 
-func createAndInitializeComponent(componentIndex int, cfg componentConfigModel) (retComponent IComponent, retErr error) {
+func createAndInitializeComponent(componentIndex int, cfg ComponentConfigModel) (retComponent IComponent, retErr error) {
 	if cfg.Disable {
 		return
 	}
@@ -78,45 +79,63 @@ func createAndInitializeComponent(componentIndex int, cfg componentConfigModel)
 	}()
 
 	retComponent = regInfo.NewComponent()
-	if err := retComponent.baseInitialize(componentIndex, tpy); err != nil {
+	if err := retComponent.baseInitialize(componentIndex, tpy, getComponentConfigID(componentIndex, cfg)); err != nil {
 		retErr = err
 		return
 	}
+	retComponent.getBaseComponent().startTimeout = time.Duration(cfg.StartTimeoutSec) * time.Second
+	retComponent.getBaseComponent().stopTimeout = time.Duration(cfg.StopTimeoutSec) * time.Second
 
-	kwData, msErr := json.Marshal(cfg.Kw)
-	if msErr != nil {
-		retErr = msErr
+	kw, decodeErr := decodeComponentKW(regInfo, cfg.Kw)
+	if decodeErr != nil {
+		retErr = decodeErr
 		return
 	}
-	kw := regInfo.NewComponentKW()
-	if kw != nil {
-		if err := json.Unmarshal(kwData, kw); err != nil {
-			retErr = err
-			return
-		}
-	}
 
 	if err := retComponent.Initialize(kw); err != nil {
+		_ = retComponent.getBaseComponent().transitStatus(ComponentFailedStatus, err)
 		retErr = err
 		return
 	}
 
+	retErr = retComponent.getBaseComponent().transitStatus(ComponentInitializedStatus, nil)
 	return
 }
 
+func genComponentID(tpy ComponentType, index int) ComponentID {
+	return ComponentID(fmt.Sprintf("%v_%d", tpy, index))
+}
+
+// getComponentConfigID returns the id configured for the component,
+// or generates one from its type and index if no id is configured.
+func getComponentConfigID(index int, cfg ComponentConfigModel) ComponentID {
+	if cfg.ID != "" {
+		return ComponentID(cfg.ID)
+	}
+	return genComponentID(ComponentType(cfg.ComponentType), index)
+}
+
 type BaseComponent struct {
-	index  int
-	tpy    ComponentType
-	id     ComponentID
-	status ComponentStatus
+	index        int
+	tpy          ComponentType
+	id           ComponentID
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+
+	statusMutex sync.RWMutex
+	componentStatusRecord
 }
 
-func (t *BaseComponent) baseInitialize(index int, tpy ComponentType) error {
+func (t *BaseComponent) baseInitialize(index int, tpy ComponentType, id ComponentID) error {
 	t.index = index
 	t.tpy = tpy
-	t.id = ComponentID(fmt.Sprintf("%v_%d", tpy, index))
+	t.id = id
 
-	return nil
+	return t.transitStatus(ComponentCreatedStatus, nil)
+}
+
+func (t *BaseComponent) getBaseComponent() *BaseComponent {
+	return t
 }
 
 func (t *BaseComponent) Initialize(args ...interface{}) error {
@@ -135,10 +154,6 @@ func (t *BaseComponent) GetID() ComponentID {
 	return t.id
 }
 
-func (t *BaseComponent) GetStatus() ComponentStatus {
-	return t.status
-}
-
 func (t *BaseComponent) Start() error {
 	return nil
 }
@@ -0,0 +1,194 @@
+package frame
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type componentSpec struct {
+	index     int
+	id        ComponentID
+	tpy       ComponentType
+	priority  int
+	cfg       ComponentConfigModel
+	dependsOn []ComponentID
+}
+
+// getDeclaredDependsOn returns the dependencies declared at registration followed by those declared in the configuration.
+func getDeclaredDependsOn(tpy ComponentType, cfg ComponentConfigModel) []string {
+	var dependsOn []string
+	if regInfo, exist := regComponentInfoMap[tpy]; exist {
+		dependsOn = append(dependsOn, regInfo.DependsOn...)
+	}
+	return append(dependsOn, cfg.DependsOn...)
+}
+
+// resolveDependsOn maps the declared dependencies of a component to component ids.
+// A dependency is either a component id, or a component type which refers to every candidate component of that type.
+func resolveDependsOn(selfID ComponentID, declared []string, idSet map[ComponentID]struct{},
+	typeMap map[ComponentType][]ComponentID) ([]ComponentID, error) {
+
+	depSet := make(map[ComponentID]struct{})
+	for _, dep := range declared {
+		if _, exist := idSet[ComponentID(dep)]; exist {
+			if ComponentID(dep) == selfID {
+				return nil, fmt.Errorf("component %v depends on itself", selfID)
+			}
+			depSet[ComponentID(dep)] = struct{}{}
+			continue
+		}
+
+		depIDs, exist := typeMap[ComponentType(dep)]
+		if !exist {
+			return nil, fmt.Errorf("component %v depends on %v, which is neither an enabled component id nor an enabled component type",
+				selfID, dep)
+		}
+		for _, depID := range depIDs {
+			if depID != selfID {
+				depSet[depID] = struct{}{}
+			}
+		}
+	}
+
+	retIDs := make([]ComponentID, 0, len(depSet))
+	for depID := range depSet {
+		retIDs = append(retIDs, depID)
+	}
+	sort.Slice(retIDs, func(i, j int) bool {
+		return retIDs[i] < retIDs[j]
+	})
+
+	return retIDs, nil
+}
+
+// sortComponentSpecs returns the enabled component configurations in topological order of their dependencies.
+// Components that do not depend on each other are ordered by their registered priority from high to low,
+// and components with the same priority keep the order of the configuration.
+func sortComponentSpecs(cfgList []ComponentConfigModel) ([]componentSpec, error) {
+	var specs []*componentSpec
+	idMap := make(map[ComponentID]int)
+	idSet := make(map[ComponentID]struct{})
+	typeMap := make(map[ComponentType][]ComponentID)
+	if err := checkComponentConfigIDs(cfgList); err != nil {
+		return nil, err
+	}
+
+	for idx, cfg := range cfgList {
+		if cfg.Disable {
+			continue
+		}
+
+		tpy := ComponentType(cfg.ComponentType)
+		spec := &componentSpec{
+			index:    idx,
+			id:       getComponentConfigID(idx, cfg),
+			tpy:      tpy,
+			priority: getComponentPriority(tpy),
+			cfg:      cfg,
+		}
+		idMap[spec.id] = len(specs)
+		idSet[spec.id] = struct{}{}
+		typeMap[tpy] = append(typeMap[tpy], spec.id)
+		specs = append(specs, spec)
+	}
+
+	// Resolve the dependencies declared at registration and in the configuration
+	for _, spec := range specs {
+		dependsOn, err := resolveDependsOn(spec.id, getDeclaredDependsOn(spec.tpy, spec.cfg), idSet, typeMap)
+		if err != nil {
+			return nil, err
+		}
+		spec.dependsOn = dependsOn
+	}
+
+	// Kahn's algorithm, always picking the ready component with the highest priority first
+	inDegree := make([]int, len(specs))
+	dependents := make([][]int, len(specs))
+	for specIdx, spec := range specs {
+		inDegree[specIdx] = len(spec.dependsOn)
+		for _, depID := range spec.dependsOn {
+			depIdx := idMap[depID]
+			dependents[depIdx] = append(dependents[depIdx], specIdx)
+		}
+	}
+
+	var ready []int
+	for specIdx := range specs {
+		if inDegree[specIdx] == 0 {
+			ready = append(ready, specIdx)
+		}
+	}
+
+	retSpecs := make([]componentSpec, 0, len(specs))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool {
+			if specs[ready[i]].priority != specs[ready[j]].priority {
+				return specs[ready[i]].priority > specs[ready[j]].priority
+			}
+			return ready[i] < ready[j]
+		})
+
+		specIdx := ready[0]
+		ready = ready[1:]
+		retSpecs = append(retSpecs, *specs[specIdx])
+
+		for _, dependentIdx := range dependents[specIdx] {
+			inDegree[dependentIdx]--
+			if inDegree[dependentIdx] == 0 {
+				ready = append(ready, dependentIdx)
+			}
+		}
+	}
+
+	if len(retSpecs) != len(specs) {
+		var cycleIDs []string
+		for specIdx, spec := range specs {
+			if inDegree[specIdx] > 0 {
+				cycleIDs = append(cycleIDs, string(spec.id))
+			}
+		}
+		return nil, fmt.Errorf("circular dependency detected, the components [%v] cannot be ordered", strings.Join(cycleIDs, ", "))
+	}
+
+	return retSpecs, nil
+}
+
+// checkComponentConfigIDs checks that the ids of all components in the configuration, configured or generated, are unique.
+func checkComponentConfigIDs(cfgList []ComponentConfigModel) error {
+	idMap := make(map[ComponentID]int, len(cfgList))
+	for idx, cfg := range cfgList {
+		id := getComponentConfigID(idx, cfg)
+		if prevIdx, exist := idMap[id]; exist {
+			return fmt.Errorf("duplicate component id %v, ComponentIndex: %v and %v", id, prevIdx, idx)
+		}
+		idMap[id] = idx
+	}
+
+	return nil
+}
+
+func getComponentPriority(tpy ComponentType) int {
+	regInfo, exist := regComponentInfoMap[tpy]
+	if !exist {
+		return ComponentPriorityLow
+	}
+	return regInfo.Priority
+}
+
+// addServiceDependencies returns the dependencies of the component extended by the providers of the services
+// it requires, so that a provider is started before and stopped after its consumers.
+func addServiceDependencies(id ComponentID, dependsOn []ComponentID) []ComponentID {
+	retIDs := append([]ComponentID(nil), dependsOn...)
+	idSet := make(map[ComponentID]struct{}, len(dependsOn))
+	for _, depID := range dependsOn {
+		idSet[depID] = struct{}{}
+	}
+	for _, provider := range getServiceProviders(id) {
+		if _, exist := idSet[provider.owner]; !exist {
+			idSet[provider.owner] = struct{}{}
+			retIDs = append(retIDs, provider.owner)
+		}
+	}
+	return retIDs
+}
@@ -0,0 +1,158 @@
+package frame
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testSortHighType     ComponentType = "TestSortHigh"
+	testSortLowType      ComponentType = "TestSortLow"
+	testSortGeneralType  ComponentType = "TestSortGeneral"
+	testSortAfterLowType ComponentType = "TestSortAfterLow"
+)
+
+func init() {
+	newComponent := func() IComponent { return nil }
+	newKW := func() IComponentKW { return nil }
+	RegisterComponentInfo(ComponentPriorityHigh, testSortHighType, newComponent, newKW)
+	RegisterComponentInfo(ComponentPriorityLow, testSortLowType, newComponent, newKW)
+	RegisterComponentInfo(ComponentPriorityGeneral, testSortGeneralType, newComponent, newKW)
+	RegisterComponentInfo(ComponentPriorityHigh, testSortAfterLowType, newComponent, newKW, string(testSortLowType))
+}
+
+func TestSortComponentSpecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfgList []ComponentConfigModel
+		wantIDs []ComponentID
+		wantErr string
+	}{
+		{
+			name: "priority from high to low",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortLowType)},
+				{ComponentType: string(testSortGeneralType)},
+				{ComponentType: string(testSortHighType)},
+			},
+			wantIDs: []ComponentID{"TestSortHigh_2", "TestSortGeneral_1", "TestSortLow_0"},
+		},
+		{
+			name: "same priority keeps the configuration order",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortGeneralType)},
+				{ComponentType: string(testSortGeneralType)},
+				{ComponentType: string(testSortGeneralType)},
+			},
+			wantIDs: []ComponentID{"TestSortGeneral_0", "TestSortGeneral_1", "TestSortGeneral_2"},
+		},
+		{
+			name: "dependency by id overrides priority",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortHighType), DependsOn: []string{"TestSortLow_1"}},
+				{ComponentType: string(testSortLowType)},
+				{ComponentType: string(testSortGeneralType)},
+			},
+			wantIDs: []ComponentID{"TestSortGeneral_2", "TestSortLow_1", "TestSortHigh_0"},
+		},
+		{
+			name: "registered dependency on a type",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortAfterLowType)},
+				{ComponentType: string(testSortLowType)},
+				{ComponentType: string(testSortLowType)},
+			},
+			wantIDs: []ComponentID{"TestSortLow_1", "TestSortLow_2", "TestSortAfterLow_0"},
+		},
+		{
+			name: "disabled components are skipped",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortLowType)},
+				{ComponentType: string(testSortHighType), Disable: true},
+				{ComponentType: string(testSortGeneralType)},
+			},
+			wantIDs: []ComponentID{"TestSortGeneral_2", "TestSortLow_0"},
+		},
+		{
+			name: "missing dependency",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortHighType), DependsOn: []string{"absent"}},
+			},
+			wantErr: "depends on absent, which is neither an enabled component id nor an enabled component type",
+		},
+		{
+			name: "dependency on a disabled component",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortHighType), DependsOn: []string{string(testSortLowType)}},
+				{ComponentType: string(testSortLowType), Disable: true},
+			},
+			wantErr: "depends on TestSortLow",
+		},
+		{
+			name: "self dependency",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortHighType), DependsOn: []string{"TestSortHigh_0"}},
+			},
+			wantErr: "component TestSortHigh_0 depends on itself",
+		},
+		{
+			name: "configured ids",
+			cfgList: []ComponentConfigModel{
+				{ID: "high", ComponentType: string(testSortHighType), DependsOn: []string{"low"}},
+				{ID: "low", ComponentType: string(testSortLowType)},
+				{ComponentType: string(testSortGeneralType)},
+			},
+			wantIDs: []ComponentID{"TestSortGeneral_2", "low", "high"},
+		},
+		{
+			name: "duplicate ids",
+			cfgList: []ComponentConfigModel{
+				{ID: "a", ComponentType: string(testSortGeneralType)},
+				{ID: "a", ComponentType: string(testSortLowType), Disable: true},
+			},
+			wantErr: "duplicate component id a",
+		},
+		{
+			name: "configured id equal to a generated id",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortGeneralType)},
+				{ID: "TestSortGeneral_0", ComponentType: string(testSortLowType)},
+			},
+			wantErr: "duplicate component id TestSortGeneral_0",
+		},
+		{
+			name: "cycle",
+			cfgList: []ComponentConfigModel{
+				{ComponentType: string(testSortGeneralType), DependsOn: []string{"TestSortGeneral_2"}},
+				{ComponentType: string(testSortGeneralType), DependsOn: []string{"TestSortGeneral_0"}},
+				{ComponentType: string(testSortGeneralType), DependsOn: []string{"TestSortGeneral_1"}},
+				{ComponentType: string(testSortLowType)},
+			},
+			wantErr: "the components [TestSortGeneral_0, TestSortGeneral_1, TestSortGeneral_2] cannot be ordered",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := sortComponentSpecs(tt.cfgList)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotIDs []ComponentID
+			for _, spec := range specs {
+				gotIDs = append(gotIDs, spec.id)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("got order %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
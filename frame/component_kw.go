@@ -0,0 +1,267 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	kwTagDefault  = "default"
+	kwTagRequired = "required"
+	kwRootPath    = "kw"
+)
+
+// IComponentKWValidator is an optional interface for KW structs. Validate is called after the KW has been decoded
+// and the default values have been applied.
+type IComponentKWValidator interface {
+	Validate() error
+}
+
+type kwField struct {
+	name  string
+	index []int
+	field reflect.StructField
+}
+
+// decodeComponentKW decodes the KW of a component strictly into the struct created by NewComponentKW.
+// Unknown keys are rejected, fields tagged with `required:"true"` must be present, and fields tagged with
+// `default:"..."` take the default value when they are absent. Errors name the path of the offending field.
+func decodeComponentKW(regInfo *RegComponentInfo, kwArgs map[string]interface{}) (IComponentKW, error) {
+	kw := regInfo.NewComponentKW()
+	if kw == nil {
+		return nil, nil
+	}
+
+	kwValue := reflect.ValueOf(kw)
+	if kwValue.Kind() != reflect.Pointer || kwValue.IsNil() {
+		return nil, fmt.Errorf("the KW of component type %v must be a non-nil pointer, got %T", regInfo.Tpy, kw)
+	}
+
+	if kwValue.Elem().Kind() == reflect.Struct {
+		if err := checkKWObject(kwRootPath, kwArgs, kwValue.Elem().Type()); err != nil {
+			return nil, err
+		}
+	}
+
+	kwData, msErr := json.Marshal(kwArgs)
+	if msErr != nil {
+		return nil, msErr
+	}
+	decoder := json.NewDecoder(bytes.NewReader(kwData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(kw); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, fmt.Errorf("invalid KW field %v.%v, expected %v but got %v", kwRootPath, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return nil, fmt.Errorf("invalid KW, %v", err)
+	}
+
+	if kwValue.Elem().Kind() == reflect.Struct {
+		if err := applyKWDefaults(kwRootPath, kwArgs, kwValue.Elem()); err != nil {
+			return nil, err
+		}
+	}
+
+	if validator, ok := kw.(IComponentKWValidator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid KW, %v", err)
+		}
+	}
+
+	return kw, nil
+}
+
+// checkKWObject rejects unknown keys and missing required fields of a JSON object decoded into the struct type.
+func checkKWObject(path string, obj map[string]interface{}, tpy reflect.Type) error {
+	fields := getKWFields(tpy)
+
+	for key := range obj {
+		if findKWField(fields, key) == nil {
+			return fmt.Errorf("invalid KW field %v.%v, unknown field", path, key)
+		}
+	}
+
+	for _, f := range fields {
+		fieldPath := path + "." + f.name
+		key, val, exist := lookupKWKey(obj, f.name)
+		if !exist || val == nil {
+			if f.field.Tag.Get(kwTagRequired) == "true" {
+				return fmt.Errorf("invalid KW field %v, required field is missing", fieldPath)
+			}
+			continue
+		}
+
+		if err := checkKWValue(path+"."+key, val, f.field.Type); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkKWValue(path string, val interface{}, tpy reflect.Type) error {
+	for tpy.Kind() == reflect.Pointer {
+		tpy = tpy.Elem()
+	}
+
+	switch tpy.Kind() {
+	case reflect.Struct:
+		if obj, ok := val.(map[string]interface{}); ok && !isKWLeafType(tpy) {
+			return checkKWObject(path, obj, tpy)
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := val.([]interface{}); ok {
+			for idx, elem := range list {
+				if err := checkKWValue(fmt.Sprintf("%v[%d]", path, idx), elem, tpy.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyKWDefaults sets the default values of the fields that are absent from the JSON object.
+func applyKWDefaults(path string, obj map[string]interface{}, value reflect.Value) error {
+	for _, f := range getKWFields(value.Type()) {
+		fieldPath := path + "." + f.name
+		fieldValue, ok := getKWFieldValue(value, f.index)
+		if !ok {
+			continue
+		}
+		_, val, exist := lookupKWKey(obj, f.name)
+
+		if !exist || val == nil {
+			if defVal, hasDefault := f.field.Tag.Lookup(kwTagDefault); hasDefault {
+				if err := setKWDefault(fieldValue, defVal); err != nil {
+					return fmt.Errorf("invalid default value %q of KW field %v, %v", defVal, fieldPath, err)
+				}
+				continue
+			}
+		}
+
+		// Nested structs take their own defaults, even if the parent object is absent
+		if fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
+			fieldValue = fieldValue.Elem()
+		}
+		if fieldValue.Kind() == reflect.Struct && !isKWLeafType(fieldValue.Type()) {
+			subObj, _ := val.(map[string]interface{})
+			if err := applyKWDefaults(fieldPath, subObj, fieldValue); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func setKWDefault(fieldValue reflect.Value, defVal string) error {
+	if fieldValue.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(defVal)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(int64(d))
+		return nil
+	}
+
+	if fieldValue.Kind() == reflect.String {
+		fieldValue.SetString(defVal)
+		return nil
+	}
+
+	return json.Unmarshal([]byte(defVal), fieldValue.Addr().Interface())
+}
+
+// getKWFields returns the fields of the struct type as encoding/json sees them, with embedded structs flattened.
+func getKWFields(tpy reflect.Type) (retFields []kwField) {
+	for i := 0; i < tpy.NumField(); i++ {
+		field := tpy.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				for _, f := range getKWFields(embeddedType) {
+					f.index = append([]int{i}, f.index...)
+					retFields = append(retFields, f)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		retFields = append(retFields, kwField{name: name, index: []int{i}, field: field})
+	}
+
+	return
+}
+
+// getKWFieldValue walks the field index, it reports false if an embedded struct pointer is nil.
+func getKWFieldValue(value reflect.Value, index []int) (reflect.Value, bool) {
+	for idx, i := range index {
+		if idx > 0 {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					return reflect.Value{}, false
+				}
+				value = value.Elem()
+			}
+		}
+		value = value.Field(i)
+	}
+	return value, true
+}
+
+// findKWField and lookupKWKey match names case-insensitively as a fallback, like encoding/json does.
+func findKWField(fields []kwField, key string) *kwField {
+	for idx := range fields {
+		if fields[idx].name == key {
+			return &fields[idx]
+		}
+	}
+	for idx := range fields {
+		if strings.EqualFold(fields[idx].name, key) {
+			return &fields[idx]
+		}
+	}
+	return nil
+}
+
+func lookupKWKey(obj map[string]interface{}, name string) (string, interface{}, bool) {
+	if val, exist := obj[name]; exist {
+		return name, val, true
+	}
+	for key, val := range obj {
+		if strings.EqualFold(key, name) {
+			return key, val, true
+		}
+	}
+	return "", nil, false
+}
+
+// isKWLeafType reports whether a struct type decodes itself and must not be checked field by field.
+func isKWLeafType(tpy reflect.Type) bool {
+	ptrType := reflect.PointerTo(tpy)
+	return ptrType.Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) ||
+		tpy == reflect.TypeOf(time.Time{})
+}
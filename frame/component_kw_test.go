@@ -0,0 +1,150 @@
+package frame
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKWLimits struct {
+	MaxConns int           `json:"max_conns" default:"16"`
+	Timeout  time.Duration `json:"timeout" default:"1500ms"`
+	Burst    int           `json:"burst" required:"true"`
+}
+
+type testKWCommon struct {
+	Region string `json:"region" default:"local"`
+}
+
+type testKW struct {
+	testKWCommon
+	Addr    string            `json:"addr" required:"true"`
+	Mode    string            `json:"mode" default:"fast"`
+	Tags    []string          `json:"tags" default:"[\"a\",\"b\"]"`
+	Limits  testKWLimits      `json:"limits"`
+	Routes  []testKWLimits    `json:"routes"`
+	Headers map[string]string `json:"headers"`
+}
+
+func (t *testKW) Validate() error {
+	if t.Mode != "fast" && t.Mode != "safe" {
+		return errors.New("mode must be fast or safe")
+	}
+	return nil
+}
+
+func TestDecodeComponentKW(t *testing.T) {
+	regInfo := &RegComponentInfo{Tpy: "TestKW", NewComponentKW: func() IComponentKW { return &testKW{} }}
+
+	tests := []struct {
+		name    string
+		kwArgs  map[string]interface{}
+		want    *testKW
+		wantErr string
+	}{
+		{
+			name:   "defaults of absent fields and nested structs",
+			kwArgs: map[string]interface{}{"addr": ":80", "limits": map[string]interface{}{"burst": 2.0}},
+			want: &testKW{testKWCommon: testKWCommon{Region: "local"}, Addr: ":80", Mode: "fast", Tags: []string{"a", "b"},
+				Limits: testKWLimits{MaxConns: 16, Timeout: 1500 * time.Millisecond, Burst: 2}},
+		},
+		{
+			name: "present fields override defaults",
+			kwArgs: map[string]interface{}{"addr": ":80", "region": "eu", "mode": "safe", "tags": []interface{}{"c"},
+				"limits": map[string]interface{}{"max_conns": 4.0, "timeout": 2e9, "burst": 1.0}},
+			want: &testKW{testKWCommon: testKWCommon{Region: "eu"}, Addr: ":80", Mode: "safe", Tags: []string{"c"},
+				Limits: testKWLimits{MaxConns: 4, Timeout: 2 * time.Second, Burst: 1}},
+		},
+		{
+			name:   "null takes the default",
+			kwArgs: map[string]interface{}{"addr": ":80", "mode": nil, "limits": map[string]interface{}{"burst": 1.0}},
+			want: &testKW{testKWCommon: testKWCommon{Region: "local"}, Addr: ":80", Mode: "fast", Tags: []string{"a", "b"},
+				Limits: testKWLimits{MaxConns: 16, Timeout: 1500 * time.Millisecond, Burst: 1}},
+		},
+		{
+			name:   "case-insensitive keys",
+			kwArgs: map[string]interface{}{"ADDR": ":80", "Limits": map[string]interface{}{"Burst": 1.0}},
+			want: &testKW{testKWCommon: testKWCommon{Region: "local"}, Addr: ":80", Mode: "fast", Tags: []string{"a", "b"},
+				Limits: testKWLimits{MaxConns: 16, Timeout: 1500 * time.Millisecond, Burst: 1}},
+		},
+		{
+			name:    "unknown field",
+			kwArgs:  map[string]interface{}{"addr": ":80", "port": 80.0},
+			wantErr: "invalid KW field kw.port, unknown field",
+		},
+		{
+			name:    "unknown nested field",
+			kwArgs:  map[string]interface{}{"addr": ":80", "limits": map[string]interface{}{"burst": 1.0, "max": 1.0}},
+			wantErr: "invalid KW field kw.limits.max, unknown field",
+		},
+		{
+			name:    "unknown field in a slice element",
+			kwArgs:  map[string]interface{}{"addr": ":80", "routes": []interface{}{map[string]interface{}{"burst": 1.0, "x": 1.0}}},
+			wantErr: "invalid KW field kw.routes[0].x, unknown field",
+		},
+		{
+			name: "map keys are not checked",
+			kwArgs: map[string]interface{}{"addr": ":80", "headers": map[string]interface{}{"x": "y"},
+				"limits": map[string]interface{}{"burst": 1.0}},
+			want: &testKW{testKWCommon: testKWCommon{Region: "local"}, Addr: ":80", Mode: "fast", Tags: []string{"a", "b"},
+				Limits: testKWLimits{MaxConns: 16, Timeout: 1500 * time.Millisecond, Burst: 1}, Headers: map[string]string{"x": "y"}},
+		},
+		{
+			name:    "missing required field",
+			kwArgs:  map[string]interface{}{"limits": map[string]interface{}{"burst": 1.0}},
+			wantErr: "invalid KW field kw.addr, required field is missing",
+		},
+		{
+			name:    "missing required nested field",
+			kwArgs:  map[string]interface{}{"addr": ":80", "limits": map[string]interface{}{}},
+			wantErr: "invalid KW field kw.limits.burst, required field is missing",
+		},
+		{
+			name:    "missing required field in a slice element",
+			kwArgs:  map[string]interface{}{"addr": ":80", "routes": []interface{}{map[string]interface{}{"max_conns": 1.0}}},
+			wantErr: "invalid KW field kw.routes[0].burst, required field is missing",
+		},
+		{
+			name:    "wrong type",
+			kwArgs:  map[string]interface{}{"addr": ":80", "limits": map[string]interface{}{"burst": "many"}},
+			wantErr: "invalid KW field kw.limits.burst, expected int but got string",
+		},
+		{
+			name:    "validation",
+			kwArgs:  map[string]interface{}{"addr": ":80", "mode": "slow", "limits": map[string]interface{}{"burst": 1.0}},
+			wantErr: "invalid KW, mode must be fast or safe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kw, err := decodeComponentKW(regInfo, tt.kwArgs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(kw, tt.want) {
+				t.Errorf("got %+v, want %+v", kw, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeComponentKWInvalidDefault(t *testing.T) {
+	type badKW struct {
+		Count int `json:"count" default:"many"`
+	}
+	regInfo := &RegComponentInfo{Tpy: "TestBadKW", NewComponentKW: func() IComponentKW { return &badKW{} }}
+
+	_, err := decodeComponentKW(regInfo, nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid default value "many" of KW field kw.count`) {
+		t.Fatalf("got error %v, want an invalid default value", err)
+	}
+}
@@ -0,0 +1,105 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IContextComponent is an optional interface for components whose startup and shutdown should observe a deadline.
+// When a component implements it, the frame calls StartContext and StopContext instead of Start and Stop.
+type IContextComponent interface {
+	StartContext(ctx context.Context) error
+	StopContext(ctx context.Context) error
+}
+
+// startComponent moves the component through Starting to Running, or to Failed if the startup returns an error
+// or overruns the start timeout of the component.
+func startComponent(ctx context.Context, component IComponent) error {
+	base := component.getBaseComponent()
+	if err := base.transitStatus(ComponentStartingStatus, nil); err != nil {
+		return err
+	}
+
+	begin := time.Now()
+	defer func() {
+		componentStartDurationGauge.WithLabelValues(string(component.GetID()), string(component.GetType())).
+			Set(time.Since(begin).Seconds())
+	}()
+
+	err := runWithTimeout(ctx, base.startTimeout, func(ctx context.Context) error {
+		if ctxComponent, ok := component.(IContextComponent); ok {
+			return ctxComponent.StartContext(ctx)
+		}
+		return component.Start()
+	})
+	if err != nil {
+		_ = base.transitStatus(ComponentFailedStatus, err)
+		return err
+	}
+
+	return base.transitStatus(ComponentRunningStatus, nil)
+}
+
+// stopComponent moves the component through Stopping to Stopped, or to Failed if the shutdown returns an error
+// or overruns the stop timeout of the component. The event subscriptions made under the id of the component are kept,
+// so that a restarted component receives the events it subscribed to in Initialize.
+func stopComponent(ctx context.Context, component IComponent) error {
+	base := component.getBaseComponent()
+	if err := base.transitStatus(ComponentStoppingStatus, nil); err != nil {
+		return err
+	}
+
+	err := runWithTimeout(ctx, base.stopTimeout, func(ctx context.Context) error {
+		if ctxComponent, ok := component.(IContextComponent); ok {
+			return ctxComponent.StopContext(ctx)
+		}
+		return component.Stop()
+	})
+	if err != nil {
+		_ = base.transitStatus(ComponentFailedStatus, err)
+		return err
+	}
+
+	return base.transitStatus(ComponentStoppedStatus, nil)
+}
+
+// cancelComponentSubscriptions cancels the given event subscriptions of the component, which is removed, replaced
+// or shut down for good.
+func cancelComponentSubscriptions(id ComponentID, subs []*Subscription) {
+	var num int
+	for _, sub := range subs {
+		if sub.Cancel() {
+			num++
+		}
+	}
+	if num > 0 {
+		getLoggerInst().DebugF("Cancelled %d event subscriptions of the component %v", num, id)
+	}
+}
+
+// runWithTimeout calls f and waits until it returns or the context, bounded by the timeout, is done.
+// A function that overruns its deadline keeps running in the background, and the deadline error is returned.
+func runWithTimeout(ctx context.Context, timeout time.Duration, f func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if ctx.Done() == nil {
+		return f(ctx)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("deadline exceeded, %w", ctx.Err())
+	}
+}
@@ -0,0 +1,86 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testContextComponent struct {
+	testStatusComponent
+	startDelay time.Duration
+	stopDelay  time.Duration
+}
+
+func (t *testContextComponent) StartContext(ctx context.Context) error {
+	return waitContext(ctx, t.startDelay)
+}
+
+func (t *testContextComponent) StopContext(ctx context.Context) error {
+	return waitContext(ctx, t.stopDelay)
+}
+
+func waitContext(ctx context.Context, delay time.Duration) error {
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestStartComponentTimeout(t *testing.T) {
+	component := &testContextComponent{startDelay: time.Second}
+	if err := component.baseInitialize(0, testStatusType, genComponentID(testStatusType, 0)); err != nil {
+		t.Fatal(err)
+	}
+	_ = component.transitStatus(ComponentInitializedStatus, nil)
+	component.startTimeout = 20 * time.Millisecond
+
+	begin := time.Now()
+	err := startComponent(context.Background(), component)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("the start returned after %v, the timeout was not observed", elapsed)
+	}
+	if status := component.GetStatus(); status != ComponentFailedStatus {
+		t.Errorf("got status %v, want Failed", status)
+	}
+}
+
+func TestStopComponentObservesParentContext(t *testing.T) {
+	component := &testContextComponent{stopDelay: time.Second}
+	if err := component.baseInitialize(0, testStatusType, genComponentID(testStatusType, 0)); err != nil {
+		t.Fatal(err)
+	}
+	_ = component.transitStatus(ComponentInitializedStatus, nil)
+	if err := startComponent(context.Background(), component); err != nil {
+		t.Fatal(err)
+	}
+
+	// No stop timeout of its own, the shutdown deadline of the caller applies
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := stopComponent(ctx, component); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v, want a deadline error", err)
+	}
+	if status := component.GetStatus(); status != ComponentFailedStatus {
+		t.Errorf("got status %v, want Failed", status)
+	}
+}
+
+func TestRunWithTimeoutWithoutDeadline(t *testing.T) {
+	wantErr := errors.New("failed")
+	err := runWithTimeout(context.Background(), 0, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			t.Error("the context has a deadline although no timeout is set")
+		}
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("got error %v, want %v", err, wantErr)
+	}
+}
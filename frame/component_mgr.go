@@ -0,0 +1,536 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	componentMgr = &ComponentMgr{}
+)
+
+func GetComponentMgr() *ComponentMgr {
+	return componentMgr
+}
+
+type componentEntry struct {
+	component IComponent
+	cfg       ComponentConfigModel
+	dependsOn []ComponentID
+	// fromConfig marks the components created from the launcher configuration, only they are reconciled
+	fromConfig bool
+}
+
+// ComponentMgr owns the components created from the launcher configuration and those added at runtime.
+// Components are kept in the order of startup.
+type ComponentMgr struct {
+	// opMutex serializes the lifecycle operations, mutex guards the entries
+	opMutex sync.Mutex
+	// opBegin is the beginning of the running lifecycle operation or of its current step in nanoseconds, 0 if none
+	// is running, and opDeadline is the deadline of the operation or of its current step, 0 if it has none
+	opBegin    atomic.Int64
+	opDeadline atomic.Int64
+	mutex      sync.RWMutex
+	entries    []*componentEntry
+	nextIndex  int
+	stopped    bool
+	// disabledMap holds the components that are disabled in the launcher configuration
+	disabledMap map[ComponentID]ComponentType
+}
+
+func (t *ComponentMgr) initialize(cfgList []ComponentConfigModel) error {
+	t.mutex.Lock()
+	t.entries = nil
+	t.nextIndex = len(cfgList)
+	t.stopped = false
+	t.mutex.Unlock()
+	t.setDisabledComponents(cfgList)
+
+	specs, sortErr := sortComponentSpecs(cfgList)
+	if sortErr != nil {
+		return fmt.Errorf("unable to resolve component dependencies, %v", sortErr)
+	}
+
+	for _, spec := range specs {
+		component, err := createAndInitializeComponent(spec.index, spec.cfg)
+		if err != nil {
+			return fmt.Errorf("unable to create and initialize component type %v, ComponentIndex: %v, Error: %v",
+				spec.cfg.ComponentType, spec.index, err)
+		}
+
+		t.mutex.Lock()
+		t.entries = append(t.entries, &componentEntry{
+			component: component, cfg: spec.cfg, dependsOn: spec.dependsOn, fromConfig: true,
+		})
+		t.mutex.Unlock()
+	}
+
+	// Fail fast if any required service has no provider
+	var ids []ComponentID
+	for _, component := range t.GetComponentList() {
+		ids = append(ids, component.GetID())
+	}
+	if err := checkServiceRequirements(ids...); err != nil {
+		return fmt.Errorf("unable to resolve component services, %v", err)
+	}
+	if err := t.addServiceDependencies(); err != nil {
+		return fmt.Errorf("unable to resolve component services, %v", err)
+	}
+
+	publishFrameEvent(EventComponentsInitialized, ComponentsInitializedEvent{ComponentIDList: ids})
+	return nil
+}
+
+// addServiceDependencies makes the providers of the required services dependencies of their consumers. A provider
+// ordered after its consumer is an error, since the consumer would start before the service it requires.
+func (t *ComponentMgr) addServiceDependencies() error {
+	entries := t.getEntryList()
+	positionMap := make(map[ComponentID]int, len(entries))
+	for idx, entry := range entries {
+		positionMap[entry.component.GetID()] = idx
+	}
+
+	var errs []error
+	for idx, entry := range entries {
+		id := entry.component.GetID()
+		for _, provider := range getServiceProviders(id) {
+			if positionMap[provider.owner] > idx {
+				errs = append(errs, fmt.Errorf("component %v requires service %v of component %v, which starts after it, "+
+					"declare the dependency in depends_on", id, provider.service, provider.owner))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	t.mutex.Lock()
+	for _, entry := range entries {
+		entry.dependsOn = addServiceDependencies(entry.component.GetID(), entry.dependsOn)
+	}
+	t.mutex.Unlock()
+	return nil
+}
+
+// startAll starts all components in the order of startup and stops at the first failure.
+func (t *ComponentMgr) startAll(ctx context.Context) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	var ids []ComponentID
+	for _, component := range t.GetComponentList() {
+		t.beginOpStep(ctx, component.getBaseComponent().startTimeout)
+		if err := startComponent(ctx, component); err != nil {
+			return fmt.Errorf("unable to start component %v, %v", component.GetID(), err)
+		}
+		getLoggerInst().InfoF("The component %v has started", component.GetID())
+		ids = append(ids, component.GetID())
+	}
+
+	publishFrameEvent(EventComponentsStarted, ComponentsStartedEvent{ComponentIDList: ids})
+	return nil
+}
+
+// stopAll stops all running components in the reverse order of startup and cancels the event subscriptions of
+// every component. Once the context is done, the remaining components are skipped. The returned error aggregates
+// the errors of all components.
+func (t *ComponentMgr) stopAll(ctx context.Context) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	t.mutex.Lock()
+	t.stopped = true
+	t.mutex.Unlock()
+
+	var errs []error
+	components := t.GetComponentList()
+	for idx := len(components) - 1; idx >= 0; idx-- {
+		component := components[idx]
+		if component.GetStatus() != ComponentRunningStatus {
+			continue
+		}
+		if ctx.Err() != nil {
+			getLoggerInst().WarningF("The shutdown deadline has been exceeded, skip stopping component %v", component.GetID())
+			errs = append(errs, fmt.Errorf("skipped stopping component %v, %v", component.GetID(), ctx.Err()))
+			continue
+		}
+		t.beginOpStep(ctx, component.getBaseComponent().stopTimeout)
+		if err := stopComponent(ctx, component); err != nil {
+			getLoggerInst().WarningF("Failed to stop component %v, %v", component.GetID(), err)
+			errs = append(errs, fmt.Errorf("unable to stop component %v, %v", component.GetID(), err))
+			continue
+		}
+		getLoggerInst().InfoF("The component %v has stopped", component.GetID())
+	}
+	for _, component := range components {
+		cancelComponentSubscriptions(component.GetID(), eventBusInst.getSubscriptions(component.GetID()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetComponentList returns all components in the order of startup.
+func (t *ComponentMgr) GetComponentList() []IComponent {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	retList := make([]IComponent, 0, len(t.entries))
+	for _, entry := range t.entries {
+		retList = append(retList, entry.component)
+	}
+	return retList
+}
+
+func (t *ComponentMgr) GetComponentStatusInfoList() []ComponentStatusInfo {
+	components := t.GetComponentList()
+	retList := make([]ComponentStatusInfo, 0, len(components))
+	for _, component := range components {
+		retList = append(retList, component.GetStatusInfo())
+	}
+	return retList
+}
+
+func (t *ComponentMgr) GetComponentByID(id ComponentID) (IComponent, bool) {
+	entry := t.getEntry(id)
+	if entry == nil {
+		return nil, false
+	}
+	return entry.component, true
+}
+
+func (t *ComponentMgr) GetComponentsByType(tpy ComponentType) (retList []IComponent) {
+	for _, component := range t.GetComponentList() {
+		if component.GetType() == tpy {
+			retList = append(retList, component)
+		}
+	}
+	return
+}
+
+// StartComponent starts a component that is initialized, stopped or failed.
+// All components it depends on must be running.
+func (t *ComponentMgr) StartComponent(ctx context.Context, id ComponentID) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	entry := t.getEntry(id)
+	if entry == nil {
+		return fmt.Errorf("component %v dose not exist", id)
+	}
+
+	return t.startEntry(ctx, entry)
+}
+
+// StopComponent stops a running component. It is rejected while any component depending on it is running.
+func (t *ComponentMgr) StopComponent(ctx context.Context, id ComponentID) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	entry := t.getEntry(id)
+	if entry == nil {
+		return fmt.Errorf("component %v dose not exist", id)
+	}
+
+	if dependents := t.getRunningDependents(id); len(dependents) > 0 {
+		return fmt.Errorf("component %v is required by the running components %v", id, dependents)
+	}
+
+	return t.stopEntry(ctx, entry)
+}
+
+// RestartComponent stops the component if it is running or failed, and then starts it again. The running components
+// that depend on it are stopped before it in the reverse order of startup, and started again after it.
+func (t *ComponentMgr) RestartComponent(ctx context.Context, id ComponentID) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	entry := t.getEntry(id)
+	if entry == nil {
+		return fmt.Errorf("component %v dose not exist", id)
+	}
+
+	// The dependents that have been stopped are started again even if the component fails to restart,
+	// a dependent whose dependency is not running fails to start and is reported as well
+	var stoppedDependents []*componentEntry
+	restartDependents := func(restartErr error) error {
+		errs := []error{restartErr}
+		for idx := len(stoppedDependents) - 1; idx >= 0; idx-- {
+			if err := t.startEntry(ctx, stoppedDependents[idx]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, dependent := range t.getRunningDependentEntries(id) {
+		if err := t.stopEntry(ctx, dependent); err != nil {
+			return restartDependents(fmt.Errorf("unable to restart component %v, %v", id, err))
+		}
+		stoppedDependents = append(stoppedDependents, dependent)
+	}
+
+	switch entry.component.GetStatus() {
+	case ComponentRunningStatus, ComponentFailedStatus:
+		if err := t.stopEntry(ctx, entry); err != nil {
+			return restartDependents(fmt.Errorf("unable to restart component %v, %v", id, err))
+		}
+	}
+	return restartDependents(t.startEntry(ctx, entry))
+}
+
+// RemoveComponent stops the component if it is running and removes it from the manager.
+// It is rejected while any component depending on it is running.
+func (t *ComponentMgr) RemoveComponent(ctx context.Context, id ComponentID) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	entry := t.getEntry(id)
+	if entry == nil {
+		return fmt.Errorf("component %v dose not exist", id)
+	}
+
+	if dependents := t.getRunningDependents(id); len(dependents) > 0 {
+		return fmt.Errorf("component %v is required by the running components %v", id, dependents)
+	}
+
+	return t.removeEntry(ctx, entry)
+}
+
+// AddComponent creates and initializes a component from the configuration at runtime, and starts it
+// unless the configuration is disabled. The dependencies are resolved against the existing components.
+func (t *ComponentMgr) AddComponent(ctx context.Context, cfg ComponentConfigModel) (IComponent, error) {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	t.mutex.Lock()
+	index := t.nextIndex
+	t.nextIndex++
+	t.mutex.Unlock()
+
+	tpy := ComponentType(cfg.ComponentType)
+	id := getComponentConfigID(index, cfg)
+	if t.getEntry(id) != nil {
+		return nil, fmt.Errorf("component %v already exists", id)
+	}
+
+	idSet := make(map[ComponentID]struct{})
+	typeMap := make(map[ComponentType][]ComponentID)
+	for _, component := range t.GetComponentList() {
+		idSet[component.GetID()] = struct{}{}
+		typeMap[component.GetType()] = append(typeMap[component.GetType()], component.GetID())
+	}
+	dependsOn, resolveErr := resolveDependsOn(id, getDeclaredDependsOn(tpy, cfg), idSet, typeMap)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	// createAndInitializeComponent skips disabled configurations, so the flag only decides whether to start
+	createCfg := cfg
+	createCfg.Disable = false
+	component, createErr := createAndInitializeComponent(index, createCfg)
+	if createErr != nil {
+		removeComponentServices(id)
+		return nil, fmt.Errorf("unable to create and initialize component type %v, ComponentIndex: %v, Error: %v",
+			tpy, index, createErr)
+	}
+	if err := checkServiceRequirements(id); err != nil {
+		removeComponentServices(id)
+		return nil, fmt.Errorf("unable to resolve the services of component %v, %v", id, err)
+	}
+
+	entry := &componentEntry{component: component, cfg: cfg, dependsOn: addServiceDependencies(id, dependsOn)}
+	t.mutex.Lock()
+	t.entries = append(t.entries, entry)
+	t.mutex.Unlock()
+	getLoggerInst().InfoF("The component %v has been added", id)
+
+	if cfg.Disable {
+		return component, nil
+	}
+
+	return component, t.startEntry(ctx, entry)
+}
+
+func (t *ComponentMgr) startEntry(ctx context.Context, entry *componentEntry) error {
+	id := entry.component.GetID()
+	for _, depID := range entry.dependsOn {
+		dep := t.getEntry(depID)
+		if dep == nil || dep.component.GetStatus() != ComponentRunningStatus {
+			return fmt.Errorf("unable to start component %v, the dependency %v is not running", id, depID)
+		}
+	}
+
+	t.beginOpStep(ctx, entry.component.getBaseComponent().startTimeout)
+	if err := startComponent(ctx, entry.component); err != nil {
+		return fmt.Errorf("unable to start component %v, %v", id, err)
+	}
+	getLoggerInst().InfoF("The component %v has started", id)
+	return nil
+}
+
+func (t *ComponentMgr) stopEntry(ctx context.Context, entry *componentEntry) error {
+	id := entry.component.GetID()
+	t.beginOpStep(ctx, entry.component.getBaseComponent().stopTimeout)
+	if err := stopComponent(ctx, entry.component); err != nil {
+		return fmt.Errorf("unable to stop component %v, %v", id, err)
+	}
+	getLoggerInst().InfoF("The component %v has stopped", id)
+	return nil
+}
+
+func (t *ComponentMgr) removeEntry(ctx context.Context, entry *componentEntry) error {
+	id := entry.component.GetID()
+	switch entry.component.GetStatus() {
+	case ComponentRunningStatus, ComponentFailedStatus:
+		if err := t.stopEntry(ctx, entry); err != nil {
+			getLoggerInst().WarningF("Failed to stop component %v before removing, %v", id, err)
+		}
+	}
+
+	t.mutex.Lock()
+	for idx, e := range t.entries {
+		if e == entry {
+			t.entries = append(t.entries[:idx], t.entries[idx+1:]...)
+			break
+		}
+	}
+	t.mutex.Unlock()
+	removeComponentServices(id)
+	cancelComponentSubscriptions(id, eventBusInst.getSubscriptions(id))
+
+	getLoggerInst().InfoF("The component %v has been removed", id)
+	return nil
+}
+
+func (t *ComponentMgr) setDisabledComponents(cfgList []ComponentConfigModel) {
+	disabledMap := make(map[ComponentID]ComponentType)
+	for idx, cfg := range cfgList {
+		if cfg.Disable {
+			disabledMap[getComponentConfigID(idx, cfg)] = ComponentType(cfg.ComponentType)
+		}
+	}
+
+	t.mutex.Lock()
+	t.disabledMap = disabledMap
+	t.mutex.Unlock()
+}
+
+func (t *ComponentMgr) getDisabledComponents() map[ComponentID]ComponentType {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	retMap := make(map[ComponentID]ComponentType, len(t.disabledMap))
+	for id, tpy := range t.disabledMap {
+		retMap[id] = tpy
+	}
+	return retMap
+}
+
+func (t *ComponentMgr) getEntry(id ComponentID) *componentEntry {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, entry := range t.entries {
+		if entry.component.GetID() == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (t *ComponentMgr) getRunningDependents(id ComponentID) (retIDs []ComponentID) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for _, entry := range t.entries {
+		if entry.component.GetStatus() != ComponentRunningStatus {
+			continue
+		}
+		for _, depID := range entry.dependsOn {
+			if depID == id {
+				retIDs = append(retIDs, entry.component.GetID())
+				break
+			}
+		}
+	}
+	return
+}
+
+// getRunningDependentEntries returns the running components that depend on the component directly or transitively,
+// in the reverse order of startup so that they can be stopped one by one.
+func (t *ComponentMgr) getRunningDependentEntries(id ComponentID) (retEntries []*componentEntry) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	requiredSet := map[ComponentID]struct{}{id: {}}
+	for _, entry := range t.entries {
+		if entry.component.GetStatus() != ComponentRunningStatus {
+			continue
+		}
+		for _, depID := range entry.dependsOn {
+			if _, required := requiredSet[depID]; required {
+				requiredSet[entry.component.GetID()] = struct{}{}
+				retEntries = append(retEntries, entry)
+				break
+			}
+		}
+	}
+
+	for i, j := 0, len(retEntries)-1; i < j; i, j = i+1, j-1 {
+		retEntries[i], retEntries[j] = retEntries[j], retEntries[i]
+	}
+	return
+}
+
+// lockOp serializes a lifecycle operation and records its beginning and the deadline of its context, so that
+// the liveness probe detects an operation that is stuck.
+func (t *ComponentMgr) lockOp(ctx context.Context) {
+	t.opMutex.Lock()
+	t.opBegin.Store(time.Now().UnixNano())
+	if deadline, ok := ctx.Deadline(); ok {
+		t.opDeadline.Store(deadline.UnixNano())
+	}
+}
+
+func (t *ComponentMgr) unlockOp() {
+	t.opBegin.Store(0)
+	t.opDeadline.Store(0)
+	t.opMutex.Unlock()
+}
+
+// beginOpStep records the start or the stop of a component within an operation whose context has no deadline,
+// the step is bounded by the timeout of the component if it has one.
+func (t *ComponentMgr) beginOpStep(ctx context.Context, timeout time.Duration) {
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+	now := time.Now()
+	t.opBegin.Store(now.UnixNano())
+	if timeout > 0 {
+		t.opDeadline.Store(now.Add(timeout).UnixNano())
+	} else {
+		t.opDeadline.Store(0)
+	}
+}
+
+// getOpOverrun returns how long the running lifecycle operation has run past its deadline, which is the deadline
+// of its context, or else of the timeout of its current step, or else the beginning of the current step.
+// It returns 0 if no operation is running or the deadline has not passed.
+func (t *ComponentMgr) getOpOverrun() time.Duration {
+	begin := t.opBegin.Load()
+	if begin == 0 {
+		return 0
+	}
+	deadline := t.opDeadline.Load()
+	if deadline == 0 {
+		deadline = begin
+	}
+	if overrun := time.Since(time.Unix(0, deadline)); overrun > 0 {
+		return overrun
+	}
+	return 0
+}
@@ -0,0 +1,243 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testMgrStoreType   ComponentType = "TestMgrStore"
+	testMgrServiceType ComponentType = "TestMgrService"
+)
+
+var (
+	testMgrEventsMutex sync.Mutex
+	testMgrEvents      []string
+)
+
+func recordTestMgrEvent(event string) {
+	testMgrEventsMutex.Lock()
+	testMgrEvents = append(testMgrEvents, event)
+	testMgrEventsMutex.Unlock()
+}
+
+func takeTestMgrEvents() []string {
+	testMgrEventsMutex.Lock()
+	defer testMgrEventsMutex.Unlock()
+	events := testMgrEvents
+	testMgrEvents = nil
+	return events
+}
+
+type testMgrKW struct {
+	FailStart bool `json:"fail_start"`
+	FailStop  bool `json:"fail_stop"`
+}
+
+type testMgrComponent struct {
+	BaseComponent
+	kw *testMgrKW
+}
+
+func (t *testMgrComponent) Initialize(kw IComponentKW) error {
+	t.kw = kw.(*testMgrKW)
+	return nil
+}
+
+func (t *testMgrComponent) Start() error {
+	if t.kw.FailStart {
+		return errors.New("start failed")
+	}
+	recordTestMgrEvent("start " + string(t.GetID()))
+	return nil
+}
+
+func (t *testMgrComponent) Stop() error {
+	if t.kw.FailStop {
+		return errors.New("stop failed")
+	}
+	recordTestMgrEvent("stop " + string(t.GetID()))
+	return nil
+}
+
+func init() {
+	newComponent := func() IComponent { return &testMgrComponent{} }
+	newKW := func() IComponentKW { return &testMgrKW{} }
+	RegisterComponentInfo(ComponentPriorityGeneral, testMgrStoreType, newComponent, newKW)
+	RegisterComponentInfo(ComponentPriorityHigh, testMgrServiceType, newComponent, newKW, string(testMgrStoreType))
+}
+
+func newTestComponentMgr(t *testing.T, cfgList ...ComponentConfigModel) *ComponentMgr {
+	t.Helper()
+	mgr := &ComponentMgr{}
+	if err := mgr.initialize(cfgList); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.startAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	takeTestMgrEvents()
+	t.Cleanup(func() {
+		_ = mgr.stopAll(context.Background())
+		takeTestMgrEvents()
+	})
+	return mgr
+}
+
+func checkTestMgrEvents(t *testing.T, want ...string) {
+	t.Helper()
+	if got := takeTestMgrEvents(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Fatalf("got events %v, want %v", got, want)
+	}
+}
+
+func TestComponentMgrStopRejectsRunningDependents(t *testing.T) {
+	mgr := newTestComponentMgr(t,
+		ComponentConfigModel{ComponentType: string(testMgrServiceType)},
+		ComponentConfigModel{ComponentType: string(testMgrStoreType)},
+	)
+	ctx := context.Background()
+
+	err := mgr.StopComponent(ctx, "TestMgrStore_1")
+	if err == nil || !strings.Contains(err.Error(), "required by the running components [TestMgrService_0]") {
+		t.Fatalf("got error %v, want a rejection", err)
+	}
+	checkTestMgrEvents(t)
+
+	// Once the dependent has stopped, the dependency can be stopped, and it must run before the dependent starts
+	if err := mgr.StopComponent(ctx, "TestMgrService_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.StopComponent(ctx, "TestMgrStore_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.StartComponent(ctx, "TestMgrService_0"); err == nil {
+		t.Fatal("a component started while its dependency was stopped")
+	}
+	if err := mgr.StartComponent(ctx, "TestMgrStore_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.StartComponent(ctx, "TestMgrService_0"); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop TestMgrService_0", "stop TestMgrStore_1", "start TestMgrStore_1", "start TestMgrService_0")
+
+	if err := mgr.StartComponent(ctx, "absent"); err == nil {
+		t.Error("starting an absent component succeeded")
+	}
+}
+
+func TestComponentMgrRestartComponent(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ComponentType: string(testMgrStoreType)})
+	ctx := context.Background()
+
+	if err := mgr.RestartComponent(ctx, "TestMgrStore_0"); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop TestMgrStore_0", "start TestMgrStore_0")
+
+	// A stopped component is only started
+	if err := mgr.StopComponent(ctx, "TestMgrStore_0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.RestartComponent(ctx, "TestMgrStore_0"); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop TestMgrStore_0", "start TestMgrStore_0")
+}
+
+func TestComponentMgrRestartComponentWithRunningDependents(t *testing.T) {
+	mgr := newTestComponentMgr(t,
+		ComponentConfigModel{ID: "service", ComponentType: string(testMgrServiceType)},
+		ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)},
+		ComponentConfigModel{ID: "sticky", ComponentType: string(testMgrStoreType), Kw: map[string]interface{}{"fail_stop": true}},
+	)
+	ctx := context.Background()
+
+	if err := mgr.RestartComponent(ctx, "store"); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop service", "stop store", "start store", "start service")
+	if service, _ := mgr.GetComponentByID("service"); service.GetStatus() != ComponentRunningStatus {
+		t.Errorf("got the dependent in status %v, want it running again", service.GetStatus())
+	}
+
+	// A component that fails to stop is not started again, and neither is its dependent
+	err := mgr.RestartComponent(ctx, "sticky")
+	if err == nil || !strings.Contains(err.Error(), "stop failed") || !strings.Contains(err.Error(), "unable to start component service") {
+		t.Fatalf("got error %v, want the stop failure", err)
+	}
+	checkTestMgrEvents(t, "stop service")
+}
+
+func TestComponentMgrAddComponent(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ComponentType: string(testMgrStoreType)})
+	ctx := context.Background()
+
+	component, err := mgr.AddComponent(ctx, ComponentConfigModel{ComponentType: string(testMgrServiceType)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if component.GetID() != "TestMgrService_1" || component.GetStatus() != ComponentRunningStatus {
+		t.Fatalf("got component %v in status %v", component.GetID(), component.GetStatus())
+	}
+	checkTestMgrEvents(t, "start TestMgrService_1")
+
+	// A disabled configuration is created but not started
+	disabled, err := mgr.AddComponent(ctx, ComponentConfigModel{ComponentType: string(testMgrStoreType), Disable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.GetStatus() != ComponentInitializedStatus {
+		t.Fatalf("got status %v for a disabled component, want Initialized", disabled.GetStatus())
+	}
+	checkTestMgrEvents(t)
+
+	// A start failure keeps the component in the manager so that it can be restarted
+	failed, err := mgr.AddComponent(ctx, ComponentConfigModel{ComponentType: string(testMgrStoreType),
+		Kw: map[string]interface{}{"fail_start": true}})
+	if err == nil {
+		t.Fatal("adding a component that fails to start succeeded")
+	}
+	if got, _ := mgr.GetComponentByID(failed.GetID()); got != failed || failed.GetStatus() != ComponentFailedStatus {
+		t.Fatalf("the failed component %v is not managed", failed.GetID())
+	}
+
+	if _, err := mgr.AddComponent(ctx, ComponentConfigModel{ComponentType: string(testMgrServiceType),
+		DependsOn: []string{"absent"}}); err == nil {
+		t.Fatal("adding a component with a missing dependency succeeded")
+	}
+	if got := len(mgr.GetComponentList()); got != 4 {
+		t.Fatalf("got %d components, want 4", got)
+	}
+}
+
+func TestComponentMgrConfiguredIDs(t *testing.T) {
+	mgr := newTestComponentMgr(t,
+		ComponentConfigModel{ID: "service", ComponentType: string(testMgrServiceType)},
+		ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)},
+	)
+	ctx := context.Background()
+
+	if err := mgr.RestartComponent(ctx, "service"); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop service", "start service")
+
+	if _, err := mgr.AddComponent(ctx, ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)}); err == nil ||
+		!strings.Contains(err.Error(), "component store already exists") {
+		t.Fatalf("got error %v, want a duplicate id", err)
+	}
+
+	// The id stays stable when the configuration is reordered
+	if err := mgr.reconcile(ctx, []ComponentConfigModel{
+		{ID: "store", ComponentType: string(testMgrStoreType)},
+		{ID: "service", ComponentType: string(testMgrServiceType)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t)
+}
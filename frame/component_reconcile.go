@@ -0,0 +1,329 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const (
+	launcherConfigKey = "__launcher__"
+)
+
+// IReconfigurableComponent is an optional interface for components that can apply a new KW in place.
+// When the KW of such a component changes in the launcher configuration, the frame calls Reconfigure
+// instead of restarting the component.
+type IReconfigurableComponent interface {
+	Reconfigure(kw IComponentKW) error
+}
+
+// launcherConfigHandler decodes the launcher configuration for the ConfigWatcher of the launcher file
+type launcherConfigHandler struct {
+	format ConfigFormat
+	mutex  sync.RWMutex
+	cfg    *LauncherConfigModel
+}
+
+func (t *launcherConfigHandler) SetConfigFormat(format ConfigFormat) {
+	t.format = format
+}
+
+func (t *launcherConfigHandler) EncodeConfig(data []byte) error {
+	candidate, err := t.DecodeConfig(data)
+	if err != nil {
+		return err
+	}
+	return t.CommitConfig(candidate)
+}
+
+func (t *launcherConfigHandler) DecodeConfig(data []byte) (interface{}, error) {
+	cfg := &LauncherConfigModel{}
+	if err := DecodeConfigData(t.format, data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate rejects a launcher configuration whose components cannot be ordered, so that the running
+// components are not reconciled against it.
+func (t *launcherConfigHandler) Validate(candidate interface{}) error {
+	cfg := candidate.(*LauncherConfigModel)
+	if err := checkComponentConfigIDs(cfg.Components); err != nil {
+		return err
+	}
+	_, err := sortComponentSpecs(cfg.Components)
+	return err
+}
+
+func (t *launcherConfigHandler) CommitConfig(candidate interface{}) error {
+	t.mutex.Lock()
+	t.cfg = candidate.(*LauncherConfigModel)
+	t.mutex.Unlock()
+	return nil
+}
+
+func (t *launcherConfigHandler) OnUpdate() {
+
+}
+
+func (t *launcherConfigHandler) GetConfigData() ([]byte, error) {
+	cfg := t.getConfig()
+	if cfg == nil {
+		return []byte{}, nil
+	}
+	return json.Marshal(cfg)
+}
+
+func (t *launcherConfigHandler) getConfig() *LauncherConfigModel {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.cfg
+}
+
+// watchLauncherConfig watches the launcher configuration file and reconciles the components
+// whenever its content changes.
+func watchLauncherConfig(launchConf string) error {
+	handler := &launcherConfigHandler{}
+	regInfo := &ConfigRegInfo{
+		Key: launcherConfigKey,
+		NewConfigHandlerFunc: func() IConfigHandler {
+			return handler
+		},
+		MustLoad: true,
+	}
+	if err := GetConfigWatcherMgr().addWatcher(launcherConfigKey, launchConf, regInfo); err != nil {
+		return err
+	}
+
+	RegisterConfigCallback(ConfigCallbackTypeUpdate, handler, func() {
+		cfg := handler.getConfig()
+		if cfg == nil {
+			return
+		}
+		if err := GetComponentMgr().reconcile(context.Background(), cfg.Components); err != nil {
+			getLoggerInst().WarningF("Failed to reconcile components against the launcher configuration, %v", err)
+		}
+	})
+
+	return nil
+}
+
+// reconcile applies the components section of a reloaded launcher configuration. Components that were removed
+// or disabled are stopped and removed, components whose KW changed are reconfigured in place or recreated,
+// and newly added components are created and started. Components added at runtime are left untouched.
+func (t *ComponentMgr) reconcile(ctx context.Context, cfgList []ComponentConfigModel) error {
+	t.lockOp(ctx)
+	defer t.unlockOp()
+
+	t.mutex.RLock()
+	stopped := t.stopped
+	t.mutex.RUnlock()
+	if stopped {
+		return nil
+	}
+
+	specs, sortErr := sortComponentSpecs(cfgList)
+	if sortErr != nil {
+		return fmt.Errorf("unable to resolve component dependencies, %v", sortErr)
+	}
+	t.mutex.Lock()
+	if t.nextIndex < len(cfgList) {
+		t.nextIndex = len(cfgList)
+	}
+	t.mutex.Unlock()
+
+	t.setDisabledComponents(cfgList)
+
+	specMap := make(map[ComponentID]componentSpec, len(specs))
+	for _, spec := range specs {
+		specMap[spec.id] = spec
+	}
+
+	// Stop and remove the components that no longer exist in the configuration
+	entries := t.getEntryList()
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		entry := entries[idx]
+		if !entry.fromConfig {
+			continue
+		}
+		if _, exist := specMap[entry.component.GetID()]; exist {
+			continue
+		}
+		_ = t.removeEntry(ctx, entry)
+	}
+
+	// Apply the changed and newly added components in the order of their dependencies
+	var configEntries []*componentEntry
+	stoppedDependents := make(map[ComponentID]struct{})
+	for _, spec := range specs {
+		entry := t.getEntry(spec.id)
+		if entry != nil && !entry.fromConfig {
+			getLoggerInst().WarningF("The component %v in the launcher configuration conflicts with a component added at runtime",
+				spec.id)
+			continue
+		}
+
+		if entry == nil {
+			newEntry, createErr := t.createEntry(spec)
+			if createErr != nil {
+				getLoggerInst().WarningF("Failed to reconcile component %v, %v", spec.id, createErr)
+				continue
+			}
+			// The new components that depend on it find it while they are started
+			t.mutex.Lock()
+			t.entries = append(t.entries, newEntry)
+			t.mutex.Unlock()
+			entry = newEntry
+		} else {
+			updatedEntry, updateErr := t.updateEntry(ctx, entry, spec, stoppedDependents)
+			if updateErr != nil {
+				// The existing component is kept with its previous configuration
+				getLoggerInst().WarningF("Failed to reconcile component %v, %v", spec.id, updateErr)
+			} else {
+				entry = updatedEntry
+			}
+		}
+		configEntries = append(configEntries, entry)
+
+		if status := entry.component.GetStatus(); status == ComponentInitializedStatus {
+			if err := t.startEntry(ctx, entry); err != nil {
+				getLoggerInst().WarningF("Failed to reconcile component %v, %v", spec.id, err)
+			}
+		}
+	}
+
+	// Keep the configured components in the new order of startup, followed by the components added at runtime
+	t.mutex.Lock()
+	for _, entry := range t.entries {
+		if !entry.fromConfig {
+			configEntries = append(configEntries, entry)
+		}
+	}
+	t.entries = configEntries
+	t.mutex.Unlock()
+
+	// Restart the dependents stopped for the replacement of their dependencies, in the order of startup
+	for _, entry := range t.getEntryList() {
+		if _, stopped := stoppedDependents[entry.component.GetID()]; !stopped {
+			continue
+		}
+		if entry.component.GetStatus() != ComponentStoppedStatus {
+			continue
+		}
+		if err := t.startEntry(ctx, entry); err != nil {
+			getLoggerInst().WarningF("Failed to restart component %v after its dependency has been replaced, %v",
+				entry.component.GetID(), err)
+		}
+	}
+
+	getLoggerInst().Info("Reconciled components against the launcher configuration")
+	return nil
+}
+
+func (t *ComponentMgr) createEntry(spec componentSpec) (*componentEntry, error) {
+	component, err := createAndInitializeComponent(spec.index, spec.cfg)
+	if err != nil {
+		removeComponentServices(spec.id)
+		return nil, fmt.Errorf("unable to create and initialize component type %v, ComponentIndex: %v, Error: %v",
+			spec.tpy, spec.index, err)
+	}
+	if err := checkServiceRequirements(spec.id); err != nil {
+		removeComponentServices(spec.id)
+		return nil, fmt.Errorf("unable to resolve the services of component %v, %v", spec.id, err)
+	}
+	getLoggerInst().InfoF("The component %v has been added", spec.id)
+
+	return &componentEntry{component: component, cfg: spec.cfg, dependsOn: addServiceDependencies(spec.id, spec.dependsOn),
+		fromConfig: true}, nil
+}
+
+// updateEntry reconfigures the component in place if only its KW changed and it implements IReconfigurableComponent,
+// otherwise a changed component is stopped and replaced by a new instance. The running components that depend on it
+// are stopped before it in the reverse order of startup and recorded in stoppedDependents, so that they are restarted
+// and resolve the services of the new instance. The new KW is decoded before anything is stopped, and the existing
+// entry is returned unchanged with the error if the component cannot be updated.
+func (t *ComponentMgr) updateEntry(ctx context.Context, entry *componentEntry, spec componentSpec,
+	stoppedDependents map[ComponentID]struct{}) (*componentEntry, error) {
+	oldCfg, newCfg := entry.cfg, spec.cfg
+	oldCfg.Kw, newCfg.Kw = nil, nil
+	cfgChanged := !reflect.DeepEqual(oldCfg, newCfg)
+	kwChanged := !reflect.DeepEqual(entry.cfg.Kw, spec.cfg.Kw)
+
+	if !cfgChanged && !kwChanged {
+		entry.dependsOn = addServiceDependencies(spec.id, spec.dependsOn)
+		return entry, nil
+	}
+
+	regInfo, exist := regComponentInfoMap[spec.tpy]
+	if !exist {
+		return entry, fmt.Errorf("component type %v dose not exist", spec.tpy)
+	}
+	kw, decodeErr := decodeComponentKW(regInfo, spec.cfg.Kw)
+	if decodeErr != nil {
+		return entry, fmt.Errorf("unable to decode the KW, %v", decodeErr)
+	}
+
+	if reconfigurable, ok := entry.component.(IReconfigurableComponent); ok && !cfgChanged {
+		if err := reconfigurable.Reconfigure(kw); err != nil {
+			return entry, fmt.Errorf("unable to reconfigure, %v", err)
+		}
+		entry.cfg = spec.cfg
+		entry.dependsOn = addServiceDependencies(spec.id, spec.dependsOn)
+		getLoggerInst().InfoF("The component %v has been reconfigured", spec.id)
+		return entry, nil
+	}
+
+	for _, dependent := range t.getRunningDependentEntries(spec.id) {
+		if err := t.stopEntry(ctx, dependent); err != nil {
+			getLoggerInst().WarningF("Failed to stop component %v before replacing its dependency %v, %v",
+				dependent.component.GetID(), spec.id, err)
+		}
+		stoppedDependents[dependent.component.GetID()] = struct{}{}
+	}
+
+	wasRunning := false
+	switch entry.component.GetStatus() {
+	case ComponentRunningStatus, ComponentFailedStatus:
+		wasRunning = true
+		if err := t.stopEntry(ctx, entry); err != nil {
+			getLoggerInst().WarningF("Failed to stop component %v before replacing, %v", spec.id, err)
+		}
+	}
+
+	// The new instance publishes its services and subscribes to its events again during Initialize
+	oldServices := getComponentServices(spec.id)
+	oldSubscriptions := eventBusInst.getSubscriptions(spec.id)
+	removeComponentServices(spec.id)
+	newEntry, createErr := t.createEntry(spec)
+	if createErr != nil {
+		// Bring the existing instance back with its services
+		restoreComponentServices(spec.id, oldServices)
+		if wasRunning {
+			if err := t.startEntry(ctx, entry); err != nil {
+				getLoggerInst().WarningF("Failed to restart component %v after its replacement failed, %v", spec.id, err)
+			}
+		}
+		return entry, createErr
+	}
+
+	t.mutex.Lock()
+	for idx, e := range t.entries {
+		if e == entry {
+			t.entries[idx] = newEntry
+			break
+		}
+	}
+	t.mutex.Unlock()
+	cancelComponentSubscriptions(spec.id, oldSubscriptions)
+
+	getLoggerInst().InfoF("The component %v has been replaced", spec.id)
+	return newEntry, nil
+}
+
+func (t *ComponentMgr) getEntryList() []*componentEntry {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return append([]*componentEntry{}, t.entries...)
+}
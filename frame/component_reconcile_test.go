@@ -0,0 +1,148 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const testReconfigType ComponentType = "TestReconcileReconfigurable"
+
+type testReconcileKW struct {
+	Port int `json:"port"`
+}
+
+type testReconfigurableComponent struct {
+	BaseComponent
+	port int
+}
+
+func (t *testReconfigurableComponent) Initialize(kw IComponentKW) error {
+	t.port = kw.(*testReconcileKW).Port
+	return nil
+}
+
+func (t *testReconfigurableComponent) Reconfigure(kw IComponentKW) error {
+	port := kw.(*testReconcileKW).Port
+	if port < 0 {
+		return errors.New("reconfigure rejected")
+	}
+	t.port = port
+	return nil
+}
+
+func init() {
+	RegisterComponentInfo(ComponentPriorityGeneral, testReconfigType, func() IComponent { return &testReconfigurableComponent{} },
+		func() IComponentKW { return &testReconcileKW{} })
+}
+
+func testReconcileConfigs(storeKW, reconfigKW map[string]interface{}) []ComponentConfigModel {
+	return []ComponentConfigModel{
+		{ComponentType: string(testMgrServiceType)},
+		{ComponentType: string(testMgrStoreType), Kw: storeKW},
+		{ComponentType: string(testReconfigType), Kw: reconfigKW},
+	}
+}
+
+func TestReconcileRestartsDependentsOfReplacedComponent(t *testing.T) {
+	ctx := context.Background()
+	mgr := newTestComponentMgr(t, testReconcileConfigs(nil, map[string]interface{}{"port": 1})...)
+	oldStore, _ := mgr.GetComponentByID("TestMgrStore_1")
+	reconfig, _ := mgr.GetComponentByID("TestReconcileReconfigurable_2")
+
+	if err := mgr.reconcile(ctx, testReconcileConfigs(map[string]interface{}{"fail_start": false},
+		map[string]interface{}{"port": 2})); err != nil {
+		t.Fatal(err)
+	}
+
+	// The dependent is stopped before the replaced component and started after the new instance
+	checkTestMgrEvents(t, "stop TestMgrService_0", "stop TestMgrStore_1", "start TestMgrStore_1", "start TestMgrService_0")
+	newStore, _ := mgr.GetComponentByID("TestMgrStore_1")
+	if newStore == oldStore || oldStore.GetStatus() != ComponentStoppedStatus {
+		t.Fatal("the store has not been replaced")
+	}
+	for _, component := range mgr.GetComponentList() {
+		if status := component.GetStatus(); status != ComponentRunningStatus {
+			t.Errorf("component %v is %v, want running", component.GetID(), status)
+		}
+	}
+
+	// Only the KW changed, so the reconfigurable component is updated in place
+	if got, _ := mgr.GetComponentByID("TestReconcileReconfigurable_2"); got != reconfig {
+		t.Error("the reconfigurable component has been replaced")
+	}
+	if port := reconfig.(*testReconfigurableComponent).port; port != 2 {
+		t.Errorf("got port %d, want 2", port)
+	}
+}
+
+func TestReconcileKeepsComponentsThatFailToUpdate(t *testing.T) {
+	ctx := context.Background()
+	mgr := newTestComponentMgr(t, testReconcileConfigs(nil, map[string]interface{}{"port": 1})...)
+	store, _ := mgr.GetComponentByID("TestMgrStore_1")
+	reconfig, _ := mgr.GetComponentByID("TestReconcileReconfigurable_2")
+
+	// A KW that cannot be decoded and a rejected reconfiguration leave both components as they were
+	if err := mgr.reconcile(ctx, testReconcileConfigs(map[string]interface{}{"fail_start": "yes"},
+		map[string]interface{}{"port": -1})); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t)
+	if got, _ := mgr.GetComponentByID("TestMgrStore_1"); got != store || store.GetStatus() != ComponentRunningStatus {
+		t.Error("the store has not been kept running")
+	}
+	if port := reconfig.(*testReconfigurableComponent).port; port != 1 {
+		t.Errorf("got port %d, want the previous port 1", port)
+	}
+
+	// Unknown KW fields are rejected in the same way
+	if err := mgr.reconcile(ctx, testReconcileConfigs(map[string]interface{}{"fail_strat": true},
+		map[string]interface{}{"prot": 2})); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t)
+	if port := reconfig.(*testReconfigurableComponent).port; port != 1 {
+		t.Errorf("got port %d, want the previous port 1", port)
+	}
+
+	// The next valid configuration still applies
+	if err := mgr.reconcile(ctx, testReconcileConfigs(nil, map[string]interface{}{"port": 3})); err != nil {
+		t.Fatal(err)
+	}
+	if port := reconfig.(*testReconfigurableComponent).port; port != 3 {
+		t.Errorf("got port %d, want 3", port)
+	}
+}
+
+func TestReconcileAddsAndRemovesComponents(t *testing.T) {
+	ctx := context.Background()
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ComponentType: string(testMgrStoreType)})
+	added, err := mgr.AddComponent(ctx, ComponentConfigModel{ComponentType: string(testReconfigType)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mgr.reconcile(ctx, []ComponentConfigModel{
+		{ComponentType: string(testMgrStoreType), Disable: true},
+		{ComponentType: string(testMgrServiceType), DependsOn: []string{"TestReconcileReconfigurable_1"}},
+	}); err == nil {
+		t.Fatal("a configured component may depend on a component added at runtime")
+	}
+
+	if err := mgr.reconcile(ctx, []ComponentConfigModel{
+		{ComponentType: string(testMgrStoreType), Disable: true},
+		{ComponentType: string(testMgrStoreType)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	checkTestMgrEvents(t, "stop TestMgrStore_0", "start TestMgrStore_1")
+
+	// The component added at runtime is kept after the configured ones
+	var gotIDs []ComponentID
+	for _, component := range mgr.GetComponentList() {
+		gotIDs = append(gotIDs, component.GetID())
+	}
+	if len(gotIDs) != 2 || gotIDs[0] != "TestMgrStore_1" || gotIDs[1] != added.GetID() {
+		t.Fatalf("got components %v", gotIDs)
+	}
+}
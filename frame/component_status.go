@@ -0,0 +1,156 @@
+package frame
+
+import (
+	"fmt"
+
+	"github.com/akley-MK4/go-tools-box/ctime"
+)
+
+const (
+	ComponentCreatedStatus ComponentStatus = iota
+	ComponentInitializedStatus
+	ComponentStartingStatus
+	ComponentRunningStatus
+	ComponentStoppingStatus
+	ComponentStoppedStatus
+	ComponentFailedStatus
+)
+
+// Deprecated: the statuses before the lifecycle state machine, kept for compatibility.
+const (
+	ComponentBaseInitStatus = ComponentCreatedStatus
+	ComponentInitStatus     = ComponentInitializedStatus
+	ComponentStartStatus    = ComponentRunningStatus
+	ComponentStopStatus     = ComponentStoppedStatus
+)
+
+var (
+	componentStatusDescMap = map[ComponentStatus]string{
+		ComponentCreatedStatus:     "Created",
+		ComponentInitializedStatus: "Initialized",
+		ComponentStartingStatus:    "Starting",
+		ComponentRunningStatus:     "Running",
+		ComponentStoppingStatus:    "Stopping",
+		ComponentStoppedStatus:     "Stopped",
+		ComponentFailedStatus:      "Failed",
+	}
+
+	// componentStatusTransitionMap lists the statuses that each status is allowed to move to
+	componentStatusTransitionMap = map[ComponentStatus][]ComponentStatus{
+		ComponentCreatedStatus:     {ComponentInitializedStatus, ComponentFailedStatus},
+		ComponentInitializedStatus: {ComponentStartingStatus},
+		ComponentStartingStatus:    {ComponentRunningStatus, ComponentFailedStatus},
+		ComponentRunningStatus:     {ComponentStoppingStatus},
+		ComponentStoppingStatus:    {ComponentStoppedStatus, ComponentFailedStatus},
+		ComponentStoppedStatus:     {ComponentStartingStatus},
+		ComponentFailedStatus:      {ComponentStartingStatus, ComponentStoppingStatus},
+	}
+)
+
+func (t ComponentStatus) String() string {
+	desc, exist := componentStatusDescMap[t]
+	if !exist {
+		return fmt.Sprintf("Unknown(%d)", uint16(t))
+	}
+	return desc
+}
+
+func (t ComponentStatus) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func checkComponentStatusTransition(from, to ComponentStatus) bool {
+	for _, status := range componentStatusTransitionMap[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ComponentStatusInfo is a snapshot of the lifecycle state of a component.
+// StatusTimestamps records the last time the component entered each status.
+type ComponentStatusInfo struct {
+	ID                 ComponentID
+	Type               ComponentType
+	Status             ComponentStatus
+	UpdateTimestamp    int64
+	StatusTimestamps   map[ComponentStatus]int64
+	LastError          string
+	LastErrorTimestamp int64
+}
+
+type componentStatusRecord struct {
+	status             ComponentStatus
+	updateTimestamp    int64
+	statusTimestamps   map[ComponentStatus]int64
+	lastError          error
+	lastErrorTimestamp int64
+}
+
+func (t *BaseComponent) transitStatus(to ComponentStatus, cause error) error {
+	from, err := t.updateStatus(to, cause)
+	if err != nil {
+		return err
+	}
+
+	// Publish outside the lock so that handlers can query the status of the component
+	publishFrameEvent(EventComponentStatusChanged, ComponentStatusChangedEvent{
+		ID:   t.id,
+		Type: t.tpy,
+		From: from,
+		To:   to,
+		Err:  cause,
+	})
+	return nil
+}
+
+func (t *BaseComponent) updateStatus(to ComponentStatus, cause error) (ComponentStatus, error) {
+	t.statusMutex.Lock()
+	defer t.statusMutex.Unlock()
+
+	from := t.status
+	if to != ComponentCreatedStatus && !checkComponentStatusTransition(from, to) {
+		return from, fmt.Errorf("illegal status transition of component %v from %v to %v", t.id, from, to)
+	}
+
+	now := ctime.CurrentTimestamp()
+	if t.statusTimestamps == nil {
+		t.statusTimestamps = make(map[ComponentStatus]int64)
+	}
+	t.status = to
+	t.updateTimestamp = now
+	t.statusTimestamps[to] = now
+	if cause != nil {
+		t.lastError = cause
+		t.lastErrorTimestamp = now
+	}
+
+	return from, nil
+}
+
+func (t *BaseComponent) GetStatus() ComponentStatus {
+	t.statusMutex.RLock()
+	defer t.statusMutex.RUnlock()
+	return t.status
+}
+
+func (t *BaseComponent) GetStatusInfo() (retInfo ComponentStatusInfo) {
+	t.statusMutex.RLock()
+	defer t.statusMutex.RUnlock()
+
+	retInfo.ID = t.id
+	retInfo.Type = t.tpy
+	retInfo.Status = t.status
+	retInfo.UpdateTimestamp = t.updateTimestamp
+	retInfo.StatusTimestamps = make(map[ComponentStatus]int64, len(t.statusTimestamps))
+	for status, timestamp := range t.statusTimestamps {
+		retInfo.StatusTimestamps[status] = timestamp
+	}
+	if t.lastError != nil {
+		retInfo.LastError = t.lastError.Error()
+		retInfo.LastErrorTimestamp = t.lastErrorTimestamp
+	}
+
+	return
+}
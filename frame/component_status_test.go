@@ -0,0 +1,142 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+const testStatusType ComponentType = "TestStatus"
+
+type testStatusComponent struct {
+	BaseComponent
+	initErr  error
+	startErr error
+	stopErr  error
+}
+
+func (t *testStatusComponent) Initialize(kw IComponentKW) error {
+	return t.initErr
+}
+
+func (t *testStatusComponent) Start() error {
+	return t.startErr
+}
+
+func (t *testStatusComponent) Stop() error {
+	return t.stopErr
+}
+
+func newTestStatusComponent(t *testing.T) *testStatusComponent {
+	t.Helper()
+	component := &testStatusComponent{}
+	if err := component.baseInitialize(0, testStatusType, genComponentID(testStatusType, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := component.transitStatus(ComponentInitializedStatus, nil); err != nil {
+		t.Fatal(err)
+	}
+	return component
+}
+
+func TestComponentLifecycle(t *testing.T) {
+	component := newTestStatusComponent(t)
+
+	if err := startComponent(context.Background(), component); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if status := component.GetStatus(); status != ComponentRunningStatus {
+		t.Fatalf("got status %v after start, want Running", status)
+	}
+	if err := startComponent(context.Background(), component); err == nil {
+		t.Fatal("starting a running component succeeded")
+	}
+
+	if err := stopComponent(context.Background(), component); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if status := component.GetStatus(); status != ComponentStoppedStatus {
+		t.Fatalf("got status %v after stop, want Stopped", status)
+	}
+	if err := stopComponent(context.Background(), component); err == nil {
+		t.Fatal("stopping a stopped component succeeded")
+	}
+
+	// A stopped component can be started again
+	if err := startComponent(context.Background(), component); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+
+	info := component.GetStatusInfo()
+	for _, status := range []ComponentStatus{ComponentCreatedStatus, ComponentInitializedStatus, ComponentStartingStatus,
+		ComponentRunningStatus, ComponentStoppingStatus, ComponentStoppedStatus} {
+		if info.StatusTimestamps[status] == 0 {
+			t.Errorf("the status %v has no timestamp", status)
+		}
+	}
+	if info.LastError != "" {
+		t.Errorf("got last error %q, want none", info.LastError)
+	}
+}
+
+func TestComponentLifecycleFailures(t *testing.T) {
+	startErr := errors.New("start failed")
+	component := newTestStatusComponent(t)
+	component.startErr = startErr
+
+	if err := startComponent(context.Background(), component); !errors.Is(err, startErr) {
+		t.Fatalf("got error %v, want %v", err, startErr)
+	}
+	info := component.GetStatusInfo()
+	if info.Status != ComponentFailedStatus || info.LastError != startErr.Error() || info.LastErrorTimestamp == 0 {
+		t.Fatalf("got %+v after a failed start, want Failed with the error", info)
+	}
+
+	// A failed component can be stopped, and a failing Stop leaves it failed
+	stopErr := errors.New("stop failed")
+	component.stopErr = stopErr
+	if err := stopComponent(context.Background(), component); !errors.Is(err, stopErr) {
+		t.Fatalf("got error %v, want %v", err, stopErr)
+	}
+	if info := component.GetStatusInfo(); info.Status != ComponentFailedStatus || info.LastError != stopErr.Error() {
+		t.Fatalf("got %+v after a failed stop, want Failed with the error", info)
+	}
+
+	// A failed component can be started again
+	component.startErr = nil
+	if err := startComponent(context.Background(), component); err != nil {
+		t.Fatalf("start after failure: %v", err)
+	}
+	if status := component.GetStatus(); status != ComponentRunningStatus {
+		t.Fatalf("got status %v, want Running", status)
+	}
+}
+
+func TestComponentStatusTransitions(t *testing.T) {
+	allowed := map[[2]ComponentStatus]bool{}
+	for from, toList := range componentStatusTransitionMap {
+		for _, to := range toList {
+			allowed[[2]ComponentStatus{from, to}] = true
+		}
+	}
+
+	statuses := []ComponentStatus{ComponentCreatedStatus, ComponentInitializedStatus, ComponentStartingStatus,
+		ComponentRunningStatus, ComponentStoppingStatus, ComponentStoppedStatus, ComponentFailedStatus}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got := checkComponentStatusTransition(from, to); got != allowed[[2]ComponentStatus{from, to}] {
+				t.Errorf("checkComponentStatusTransition(%v, %v) = %v", from, to, got)
+			}
+		}
+	}
+
+	// Running can only be left through Stopping
+	for _, to := range []ComponentStatus{ComponentStoppedStatus, ComponentStartingStatus, ComponentInitializedStatus} {
+		if checkComponentStatusTransition(ComponentRunningStatus, to) {
+			t.Errorf("Running may move to %v", to)
+		}
+	}
+	if got := ComponentStatus(99).String(); got != "Unknown(99)" {
+		t.Errorf("got %q for an unknown status", got)
+	}
+}
@@ -0,0 +1,163 @@
+package frame
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// k8sConfigMapDataDir is the symlink that Kubernetes swaps atomically when a mounted ConfigMap changes,
+	// the files of the ConfigMap are symlinks through it and do not produce events of their own
+	k8sConfigMapDataDir = "..data"
+)
+
+// configDirWatcher watches a directory with one fsnotify watcher and dispatches the events to the
+// ConfigWatchers of the files in the directory.
+type configDirWatcher struct {
+	dir                   string
+	watcher               *fsnotify.Watcher
+	retryWatchIntervalSec uint64
+	started               bool
+	done                  chan struct{}
+	stopOnce              sync.Once
+
+	mutex          sync.RWMutex
+	watched        bool
+	configWatchers []*ConfigWatcher
+}
+
+func newConfigDirWatcher(dir string, retryWatchIntervalSec uint64) (*configDirWatcher, error) {
+	w, newWatcherErr := fsnotify.NewWatcher()
+	if newWatcherErr != nil {
+		return nil, fmt.Errorf("failed to create watcher, %v", newWatcherErr)
+	}
+
+	return &configDirWatcher{
+		dir:                   dir,
+		watcher:               w,
+		retryWatchIntervalSec: retryWatchIntervalSec,
+		done:                  make(chan struct{}),
+	}, nil
+}
+
+func (t *configDirWatcher) addConfigWatcher(configWatcher *ConfigWatcher) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.configWatchers = append(t.configWatchers, configWatcher)
+}
+
+func (t *configDirWatcher) getConfigWatchers() []*ConfigWatcher {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return append([]*ConfigWatcher(nil), t.configWatchers...)
+}
+
+func (t *configDirWatcher) isWatched() bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.watched
+}
+
+func (t *configDirWatcher) watch() error {
+	if err := t.watcher.Add(t.dir); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.watched = true
+	t.mutex.Unlock()
+	return nil
+}
+
+// start runs the loop of the directory watcher once, the caller must hold the mutex of the ConfigWatcherMgr.
+func (t *configDirWatcher) start() {
+	if t.started {
+		return
+	}
+	t.started = true
+	go t.loopWatch()
+}
+
+func (t *configDirWatcher) stop() error {
+	var err error
+	t.stopOnce.Do(func() {
+		for _, configWatcher := range t.getConfigWatchers() {
+			configWatcher.stopPendingChange()
+		}
+		close(t.done)
+		err = t.watcher.Close()
+	})
+	return err
+}
+
+func (t *configDirWatcher) loopWatch() {
+	if !t.isWatched() {
+		if !t.intervalRetryWatch() {
+			return
+		}
+		// The files may have been created before the directory is watched
+		for _, configWatcher := range t.getConfigWatchers() {
+			configWatcher.reload()
+		}
+	}
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case err, ok := <-t.watcher.Errors:
+			if !ok {
+				return
+			}
+			getLoggerInst().WarningF("Failed to watch the configuration directory %v, %v", t.dir, err)
+		case e, ok := <-t.watcher.Events:
+			if !ok {
+				return
+			}
+			t.dispatch(e)
+		}
+	}
+}
+
+// dispatch hands the event to the ConfigWatchers whose file it concerns. A swap of the Kubernetes ConfigMap
+// data directory concerns all files in the directory.
+func (t *configDirWatcher) dispatch(e fsnotify.Event) {
+	name := path.Clean(e.Name)
+	swapped := path.Base(name) == k8sConfigMapDataDir && e.Has(fsnotify.Create)
+	for _, configWatcher := range t.getConfigWatchers() {
+		if swapped {
+			configWatcher.scheduleChange(false)
+			continue
+		}
+		if name == configWatcher.path && e.Op != fsnotify.Chmod {
+			configWatcher.scheduleChange(e.Has(fsnotify.Create))
+		}
+	}
+}
+
+// intervalRetryWatch retries to watch the directory until it succeeds, it returns false if the watcher is stopped.
+func (t *configDirWatcher) intervalRetryWatch() bool {
+	getLoggerInst().InfoF("The configuration directory %v is not watched, start timing check operation", t.dir)
+
+	var retryTotal int
+	for {
+		select {
+		case <-t.done:
+			return false
+		case <-time.After(time.Second * time.Duration(t.retryWatchIntervalSec)):
+		}
+
+		retryTotal += 1
+		if err := t.watch(); err != nil {
+			getLoggerInst().DebugF("Failed to watch path %s, RetryTotal: %d, Err: %v", t.dir, retryTotal, err)
+			continue
+		}
+
+		getLoggerInst().InfoF("Watched path %s, RetryTotal: %d", t.dir, retryTotal)
+		return true
+	}
+}
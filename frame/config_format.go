@@ -0,0 +1,521 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/titanous/json5"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the format of a configuration file. Whatever the format, the content is decoded
+// according to the json tags of the target, so the same struct serves all formats.
+type ConfigFormat string
+
+const (
+	ConfigFormatJSON ConfigFormat = "json"
+	// ConfigFormatJSONC is JSON with // and /* */ comments and trailing commas
+	ConfigFormatJSONC ConfigFormat = "jsonc"
+	// ConfigFormatJSON5 is JSON5, which also allows unquoted keys, single-quoted strings and hexadecimal numbers
+	ConfigFormatJSON5 ConfigFormat = "json5"
+	ConfigFormatYAML  ConfigFormat = "yaml"
+	ConfigFormatTOML  ConfigFormat = "toml"
+	// ConfigFormatINI is INI, a section becomes an object and the dots of a section name nest the objects
+	ConfigFormatINI ConfigFormat = "ini"
+)
+
+const (
+	maxYAMLAliasDepth = 64
+)
+
+var (
+	configFormatNames = map[string]ConfigFormat{
+		"json":  ConfigFormatJSON,
+		"jsonc": ConfigFormatJSONC,
+		"json5": ConfigFormatJSON5,
+		"yaml":  ConfigFormatYAML,
+		"yml":   ConfigFormatYAML,
+		"toml":  ConfigFormatTOML,
+		"ini":   ConfigFormatINI,
+	}
+	yamlErrorLineRegexp = regexp.MustCompile(`line (\d+)`)
+)
+
+// ParseConfigFormat parses the name of a format, such as "yaml" or "yml". An empty name returns an empty format.
+func ParseConfigFormat(name string) (ConfigFormat, error) {
+	if name == "" {
+		return "", nil
+	}
+	format, ok := configFormatNames[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unsupported configuration format %v", name)
+	}
+	return format, nil
+}
+
+// GetConfigFormatByPath returns the format of the file by its extension, JSON is used for unknown extensions.
+func GetConfigFormatByPath(filePath string) ConfigFormat {
+	if format, ok := configFormatNames[strings.ToLower(strings.TrimPrefix(path.Ext(filePath), "."))]; ok {
+		return format
+	}
+	return ConfigFormatJSON
+}
+
+// resolveConfigFormat returns the explicit format, or the format of the file extension if it is empty.
+func resolveConfigFormat(format ConfigFormat, filePath string) ConfigFormat {
+	if format != "" {
+		return format
+	}
+	return GetConfigFormatByPath(filePath)
+}
+
+// IConfigFormatHandler is an optional interface of a configuration handler that decodes the file itself,
+// the frame sets the format of the file before the first load.
+type IConfigFormatHandler interface {
+	SetConfigFormat(format ConfigFormat)
+}
+
+// ConfigDecodeError is an error of decoding a configuration, Line and Column start at 1 and are 0 if unknown.
+type ConfigDecodeError struct {
+	Format ConfigFormat
+	Line   int
+	Column int
+	Err    error
+}
+
+func (t *ConfigDecodeError) Error() string {
+	if t.Line > 0 && t.Column > 0 {
+		return fmt.Sprintf("%v decode error at line %d, column %d, %v", t.Format, t.Line, t.Column, t.Err)
+	}
+	if t.Line > 0 {
+		return fmt.Sprintf("%v decode error at line %d, %v", t.Format, t.Line, t.Err)
+	}
+	return fmt.Sprintf("%v decode error, %v", t.Format, t.Err)
+}
+
+func (t *ConfigDecodeError) Unwrap() error {
+	return t.Err
+}
+
+// DecodeConfigData decodes the data of the format into v according to the json tags of v.
+// The returned error is a *ConfigDecodeError with the line and the column of the failure when they are known.
+func DecodeConfigData(format ConfigFormat, data []byte, v interface{}) error {
+	switch format {
+	case ConfigFormatJSON, "":
+		return decodeJSONConfig(ConfigFormatJSON, data, v, nil)
+	case ConfigFormatJSONC:
+		// Comments and trailing commas are blanked out, so the offsets of the stripped data match the original data
+		return decodeJSONConfig(format, stripJSONComments(data), v, nil)
+	case ConfigFormatJSON5:
+		return decodeJSON5Config(data, v)
+	case ConfigFormatYAML:
+		jsonData, marks, err := convertYAMLToJSON(data)
+		if err != nil {
+			return err
+		}
+		return decodeJSONConfig(format, jsonData, v, marks)
+	case ConfigFormatTOML:
+		return decodeTOMLConfig(data, v)
+	case ConfigFormatINI:
+		jsonData, marks, err := convertINIToJSON(data)
+		if err != nil {
+			return err
+		}
+		return decodeJSONConfig(format, jsonData, v, marks)
+	}
+	return fmt.Errorf("unsupported configuration format %v", format)
+}
+
+// jsonPositionMark maps an offset of the converted JSON data to the position of the value in the original data.
+type jsonPositionMark struct {
+	offset int
+	line   int
+	column int
+}
+
+// decodeJSONConfig decodes the JSON data into v. The offset of a failure is mapped to the original data
+// through the marks, or located in the data itself if there are no marks.
+func decodeJSONConfig(format ConfigFormat, data []byte, v interface{}, marks []jsonPositionMark) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+
+	decodeErr := &ConfigDecodeError{Format: format, Err: err}
+	if offset < 0 {
+		return decodeErr
+	}
+	if marks == nil {
+		decodeErr.Line, decodeErr.Column = offsetToLineColumn(data, int(offset))
+		return decodeErr
+	}
+
+	// The offset is after the value that failed, so the position is the one of the last value starting before it
+	idx := sort.Search(len(marks), func(i int) bool {
+		return marks[i].offset >= int(offset)
+	})
+	if idx > 0 {
+		decodeErr.Line, decodeErr.Column = marks[idx-1].line, marks[idx-1].column
+	}
+	return decodeErr
+}
+
+// decodeJSON5Config decodes the JSON5 data into v, the decoder follows the json tags like encoding/json.
+func decodeJSON5Config(data []byte, v interface{}) error {
+	err := json5.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	decodeErr := &ConfigDecodeError{Format: ConfigFormatJSON5, Err: err}
+	var syntaxErr *json5.SyntaxError
+	var typeErr *json5.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		decodeErr.Line, decodeErr.Column = offsetToLineColumn(data, int(syntaxErr.Offset))
+	} else if errors.As(err, &typeErr) {
+		decodeErr.Line, decodeErr.Column = offsetToLineColumn(data, int(typeErr.Offset))
+	}
+	return decodeErr
+}
+
+func offsetToLineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// stripJSONComments replaces the comments and the trailing commas of JSON with spaces, keeping the line breaks.
+func stripJSONComments(data []byte) []byte {
+	ret := make([]byte, len(data))
+	copy(ret, data)
+
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if ret[i] != '\n' && ret[i] != '\r' {
+				ret[i] = ' '
+			}
+		}
+	}
+
+	inString := false
+	for i := 0; i < len(ret); i++ {
+		c := ret[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(ret) && ret[i+1] == '/':
+			end := bytes.IndexByte(ret[i:], '\n')
+			if end < 0 {
+				end = len(ret) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && i+1 < len(ret) && ret[i+1] == '*':
+			end := bytes.Index(ret[i+2:], []byte("*/"))
+			if end < 0 {
+				blank(i, len(ret))
+				return ret
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		}
+	}
+
+	// Blank the commas followed only by white spaces before a closing bracket
+	inString = false
+	for i := 0; i < len(ret); i++ {
+		c := ret[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			continue
+		}
+		if c != ',' {
+			continue
+		}
+		next := i + 1
+		for next < len(ret) && (ret[next] == ' ' || ret[next] == '\t' || ret[next] == '\n' || ret[next] == '\r') {
+			next++
+		}
+		if next < len(ret) && (ret[next] == '}' || ret[next] == ']') {
+			ret[i] = ' '
+		}
+	}
+
+	return ret
+}
+
+// convertYAMLToJSON converts the YAML data to JSON and marks the position of every value,
+// so that the failures of decoding the JSON data are reported at the position in the YAML data.
+func convertYAMLToJSON(data []byte) ([]byte, []jsonPositionMark, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		decodeErr := &ConfigDecodeError{Format: ConfigFormatYAML, Err: err}
+		if matches := yamlErrorLineRegexp.FindStringSubmatch(err.Error()); len(matches) > 1 {
+			decodeErr.Line, _ = strconv.Atoi(matches[1])
+		}
+		return nil, nil, decodeErr
+	}
+
+	converter := &yamlJSONConverter{}
+	if err := converter.writeNode(&root, 0); err != nil {
+		return nil, nil, err
+	}
+	return converter.buf.Bytes(), converter.marks, nil
+}
+
+type yamlJSONConverter struct {
+	buf   bytes.Buffer
+	marks []jsonPositionMark
+}
+
+func (t *yamlJSONConverter) nodeError(node *yaml.Node, err error) error {
+	return &ConfigDecodeError{Format: ConfigFormatYAML, Line: node.Line, Column: node.Column, Err: err}
+}
+
+func (t *yamlJSONConverter) writeNode(node *yaml.Node, aliasDepth int) error {
+	if node.Kind != yaml.DocumentNode && node.Kind != yaml.AliasNode {
+		t.marks = append(t.marks, jsonPositionMark{offset: t.buf.Len(), line: node.Line, column: node.Column})
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			t.buf.WriteString("null")
+			return nil
+		}
+		return t.writeNode(node.Content[0], aliasDepth)
+	case yaml.AliasNode:
+		if aliasDepth >= maxYAMLAliasDepth {
+			return t.nodeError(node, errors.New("too many nested aliases"))
+		}
+		return t.writeNode(node.Alias, aliasDepth+1)
+	case yaml.SequenceNode:
+		t.buf.WriteByte('[')
+		for idx, item := range node.Content {
+			if idx > 0 {
+				t.buf.WriteByte(',')
+			}
+			if err := t.writeNode(item, aliasDepth); err != nil {
+				return err
+			}
+		}
+		t.buf.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		t.buf.WriteByte('{')
+		first := true
+		if err := t.writeMappingContent(node, aliasDepth, &first); err != nil {
+			return err
+		}
+		t.buf.WriteByte('}')
+		return nil
+	case yaml.ScalarNode:
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return t.nodeError(node, err)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return t.nodeError(node, err)
+		}
+		t.buf.Write(b)
+		return nil
+	}
+
+	return t.nodeError(node, fmt.Errorf("unsupported node kind %v", node.Kind))
+}
+
+// writeMappingContent writes the pairs of the mapping. The pairs of the merge keys are written first,
+// so that the explicit keys, which are decoded later, take precedence.
+func (t *yamlJSONConverter) writeMappingContent(node *yaml.Node, aliasDepth int, first *bool) error {
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		key, value := node.Content[idx], node.Content[idx+1]
+		if key.Tag != "!!merge" {
+			continue
+		}
+		merged := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			merged = value.Content
+		}
+		for _, m := range merged {
+			depth := aliasDepth
+			for m.Kind == yaml.AliasNode && depth < maxYAMLAliasDepth {
+				m, depth = m.Alias, depth+1
+			}
+			if m.Kind != yaml.MappingNode {
+				return t.nodeError(value, errors.New("the value of a merge key must be a mapping"))
+			}
+			if err := t.writeMappingContent(m, depth, first); err != nil {
+				return err
+			}
+		}
+	}
+
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		key, value := node.Content[idx], node.Content[idx+1]
+		if key.Tag == "!!merge" {
+			continue
+		}
+		if key.Kind != yaml.ScalarNode {
+			return t.nodeError(key, errors.New("the key of a mapping must be a scalar"))
+		}
+		if !*first {
+			t.buf.WriteByte(',')
+		}
+		*first = false
+		keyData, _ := json.Marshal(key.Value)
+		t.buf.Write(keyData)
+		t.buf.WriteByte(':')
+		if err := t.writeNode(value, aliasDepth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeTOMLConfig decodes the TOML data through JSON. A failure of decoding the JSON data is reported
+// at the position of the TOML key of the failed field.
+func decodeTOMLConfig(data []byte, v interface{}) error {
+	var mapping map[string]interface{}
+	if _, err := toml.Decode(string(data), &mapping); err != nil {
+		decodeErr := &ConfigDecodeError{Format: ConfigFormatTOML, Err: err}
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			decodeErr.Line, decodeErr.Column = parseErr.Position.Line, parseErr.Position.Col
+			if decodeErr.Column <= 0 && parseErr.Position.Start > 0 {
+				decodeErr.Line, decodeErr.Column = offsetToLineColumn(data, parseErr.Position.Start)
+			}
+		}
+		return decodeErr
+	}
+
+	jsonData, marshalErr := json.Marshal(mapping)
+	if marshalErr != nil {
+		return &ConfigDecodeError{Format: ConfigFormatTOML, Err: marshalErr}
+	}
+	if err := json.Unmarshal(jsonData, v); err != nil {
+		decodeErr := &ConfigDecodeError{Format: ConfigFormatTOML, Err: err}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			decodeErr.Line, decodeErr.Column = findTOMLKeyPosition(data, mapping, strings.Split(typeErr.Field, "."))
+		}
+		return decodeErr
+	}
+
+	return nil
+}
+
+var errTOMLPositionProbe = errors.New("position probe")
+
+// tomlPositionProbe fails to decode any value, so that the TOML decoder reports the position of its key.
+type tomlPositionProbe struct{}
+
+func (tomlPositionProbe) UnmarshalTOML(interface{}) error {
+	return errTOMLPositionProbe
+}
+
+// findTOMLKeyPosition returns the position of the key of the JSON field path as the TOML decoder reports it,
+// or zeros if the key is not found. The data is decoded again into a type that only has the fields of the path
+// and a probe at the end of it, and for an array of tables the key of the first table is reported.
+func findTOMLKeyPosition(data []byte, mapping map[string]interface{}, fieldPath []string) (int, int) {
+	probeType, ok := buildTOMLProbeType(mapping, fieldPath)
+	if !ok {
+		return 0, 0
+	}
+	_, err := toml.Decode(string(data), reflect.New(probeType).Interface())
+	var parseErr toml.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Message != errTOMLPositionProbe.Error() {
+		return 0, 0
+	}
+	return parseErr.Position.Line, parseErr.Position.Col
+}
+
+func buildTOMLProbeType(value interface{}, fieldPath []string) (reflect.Type, bool) {
+	if len(fieldPath) == 0 {
+		return reflect.TypeOf(tomlPositionProbe{}), true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// encoding/json matches the keys case-insensitively, so the TOML key may differ in case from the field
+		key, found := fieldPath[0], false
+		if _, found = v[key]; !found {
+			for name := range v {
+				if strings.EqualFold(name, fieldPath[0]) {
+					key, found = name, true
+					break
+				}
+			}
+		}
+		if !found || strings.Contains(key, ",") {
+			return nil, false
+		}
+		elemType, ok := buildTOMLProbeType(v[key], fieldPath[1:])
+		if !ok {
+			return nil, false
+		}
+		return reflect.StructOf([]reflect.StructField{{
+			Name: "Probe",
+			Type: elemType,
+			Tag:  reflect.StructTag("toml:" + strconv.Quote(key)),
+		}}), true
+	case []map[string]interface{}:
+		if len(v) == 0 {
+			return nil, false
+		}
+		elemType, ok := buildTOMLProbeType(v[0], fieldPath)
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(elemType), true
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, false
+		}
+		elemType, ok := buildTOMLProbeType(v[0], fieldPath)
+		if !ok {
+			return nil, false
+		}
+		return reflect.SliceOf(elemType), true
+	}
+	return nil, false
+}
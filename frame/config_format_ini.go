@@ -0,0 +1,259 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+var iniNumberRegexp = regexp.MustCompile(`^-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?$`)
+
+// iniValue is a scalar, an array of scalars or a table of the INI data, with the position where it is defined.
+type iniValue struct {
+	line    int
+	column  int
+	scalar  []byte
+	isArray bool
+	items   []*iniValue
+	table   *iniTable
+}
+
+// iniTable keeps the keys in the order of definition, so that the JSON data follows the INI data.
+type iniTable struct {
+	keys   []string
+	values map[string]*iniValue
+}
+
+func newINITable() *iniTable {
+	return &iniTable{values: make(map[string]*iniValue)}
+}
+
+func (t *iniTable) set(key string, value *iniValue) {
+	t.keys = append(t.keys, key)
+	t.values[key] = value
+}
+
+// iniPosition is the position of a section header or of the value of a key.
+type iniPosition struct {
+	line   int
+	column int
+}
+
+// convertINIToJSON converts the INI data to JSON and marks the position of every value, so that the failures
+// of decoding the JSON data are reported at the position in the INI data.
+//
+// The data is parsed by gopkg.in/ini.v1. A [section] becomes an object, and the dots of a section name nest
+// the objects. A key ending with [] appends its value to an array, any other key must be defined once.
+// A ';' or '#' after a space starts a comment. An unquoted value is a boolean if it is true or false, a number
+// if it is a JSON number, and a string otherwise, a value quoted by double or single quotes is always a string.
+func convertINIToJSON(data []byte) ([]byte, []jsonPositionMark, error) {
+	file, err := ini.LoadSources(ini.LoadOptions{
+		AllowShadows:             true,
+		SpaceBeforeInlineComment: true,
+		PreserveSurroundedQuote:  true,
+	}, data)
+	if err != nil {
+		return nil, nil, newINILoadError(data, err)
+	}
+
+	positions := scanINIPositions(data)
+	root := newINITable()
+	for _, section := range file.Sections() {
+		table := root
+		if section.Name() != ini.DefaultSection {
+			table, err = root.sectionTable(section.Name(), positions[section.Name()])
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		for _, key := range section.Keys() {
+			keyPositions := positions[iniKeyPositionName(section.Name(), key.Name())]
+			if err := table.setKey(key.Name(), key.ValueWithShadows(), keyPositions); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	converter := &iniJSONConverter{}
+	converter.writeTable(root)
+	return converter.buf.Bytes(), converter.marks, nil
+}
+
+func iniError(position iniPosition, err error) error {
+	return &ConfigDecodeError{Format: ConfigFormatINI, Line: position.line, Column: position.column, Err: err}
+}
+
+// newINILoadError reports an error of the INI parser at the line it names, the parser does not keep the line numbers.
+func newINILoadError(data []byte, err error) error {
+	var badLine string
+	var delimiterErr ini.ErrDelimiterNotFound
+	var emptyKeyErr ini.ErrEmptyKeyName
+	if errors.As(err, &delimiterErr) {
+		badLine = delimiterErr.Line
+	} else if errors.As(err, &emptyKeyErr) {
+		badLine = emptyKeyErr.Line
+	}
+
+	badLine = strings.TrimSpace(badLine)
+	if badLine != "" {
+		for idx, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == badLine {
+				column := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+				return iniError(iniPosition{line: idx + 1, column: column}, err)
+			}
+		}
+	}
+	return iniError(iniPosition{}, err)
+}
+
+func iniKeyPositionName(section, key string) string {
+	return section + "\x00" + key
+}
+
+// scanINIPositions returns the positions of the section headers by their names, and the positions of the values
+// of every key by iniKeyPositionName, in the order of the lines.
+func scanINIPositions(data []byte) map[string][]iniPosition {
+	positions := make(map[string][]iniPosition)
+	section := ini.DefaultSection
+	for idx, line := range strings.Split(string(data), "\n") {
+		text := strings.TrimLeft(line, " \t\ufeff")
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+		column := len(line) - len(text) + 1
+
+		if text[0] == '[' {
+			if end := strings.LastIndexByte(text, ']'); end > 0 {
+				section = strings.TrimSpace(text[1:end])
+				positions[section] = append(positions[section], iniPosition{line: idx + 1, column: column})
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(text, "=:")
+		if sep < 0 {
+			continue
+		}
+		value := strings.TrimLeft(text[sep+1:], " \t")
+		name := iniKeyPositionName(section, strings.TrimSpace(text[:sep]))
+		positions[name] = append(positions[name], iniPosition{line: idx + 1, column: column + len(text) - len(value)})
+	}
+	return positions
+}
+
+// sectionTable returns the table of the section, creating the tables of the section path as needed.
+func (t *iniTable) sectionTable(name string, positions []iniPosition) (*iniTable, error) {
+	var position iniPosition
+	if len(positions) > 0 {
+		position = positions[0]
+	}
+
+	table := t
+	for _, part := range strings.Split(name, ".") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, iniError(position, fmt.Errorf("invalid section name %v", name))
+		}
+		value, exists := table.values[part]
+		if !exists {
+			value = &iniValue{line: position.line, column: position.column, table: newINITable()}
+			table.set(part, value)
+		}
+		if value.table == nil {
+			return nil, iniError(position, fmt.Errorf("section %v conflicts with the key %v at line %d",
+				name, part, value.line))
+		}
+		table = value.table
+	}
+	return table, nil
+}
+
+func (t *iniTable) setKey(name string, values []string, positions []iniPosition) error {
+	positionOf := func(idx int) iniPosition {
+		if idx < len(positions) {
+			return positions[idx]
+		}
+		return iniPosition{}
+	}
+
+	key := strings.TrimSpace(strings.TrimSuffix(name, "[]"))
+	if key == "" {
+		return iniError(positionOf(0), errors.New("empty key"))
+	}
+	if existing, exists := t.values[key]; exists {
+		return iniError(positionOf(0), fmt.Errorf("key %v conflicts with the definition at line %d", key, existing.line))
+	}
+
+	if !strings.HasSuffix(name, "[]") {
+		if len(values) > 1 {
+			return iniError(positionOf(1), fmt.Errorf("key %v is defined more than once", key))
+		}
+		position := positionOf(0)
+		t.set(key, &iniValue{line: position.line, column: position.column, scalar: parseINIScalar(values[0])})
+		return nil
+	}
+
+	position := positionOf(0)
+	array := &iniValue{line: position.line, column: position.column, isArray: true}
+	for idx, value := range values {
+		position := positionOf(idx)
+		array.items = append(array.items, &iniValue{line: position.line, column: position.column, scalar: parseINIScalar(value)})
+	}
+	t.set(key, array)
+	return nil
+}
+
+// parseINIScalar returns the JSON data of the value.
+func parseINIScalar(text string) []byte {
+	if len(text) >= 2 && (text[0] == '"' || text[0] == '\'') && text[len(text)-1] == text[0] {
+		text = text[1 : len(text)-1]
+	} else if text == "true" || text == "false" || iniNumberRegexp.MatchString(text) {
+		return []byte(text)
+	}
+	data, _ := json.Marshal(text)
+	return data
+}
+
+type iniJSONConverter struct {
+	buf   bytes.Buffer
+	marks []jsonPositionMark
+}
+
+func (t *iniJSONConverter) writeTable(table *iniTable) {
+	t.buf.WriteByte('{')
+	for idx, key := range table.keys {
+		if idx > 0 {
+			t.buf.WriteByte(',')
+		}
+		keyData, _ := json.Marshal(key)
+		t.buf.Write(keyData)
+		t.buf.WriteByte(':')
+		t.writeValue(table.values[key])
+	}
+	t.buf.WriteByte('}')
+}
+
+func (t *iniJSONConverter) writeValue(value *iniValue) {
+	t.marks = append(t.marks, jsonPositionMark{offset: t.buf.Len(), line: value.line, column: value.column})
+	switch {
+	case value.table != nil:
+		t.writeTable(value.table)
+	case value.isArray:
+		t.buf.WriteByte('[')
+		for idx, item := range value.items {
+			if idx > 0 {
+				t.buf.WriteByte(',')
+			}
+			t.writeValue(item)
+		}
+		t.buf.WriteByte(']')
+	default:
+		t.buf.Write(value.scalar)
+	}
+}
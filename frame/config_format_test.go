@@ -0,0 +1,160 @@
+package frame
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testFormatServer struct {
+	Name   string   `json:"name"`
+	Port   int      `json:"port"`
+	Debug  bool     `json:"debug"`
+	Ratio  float64  `json:"ratio"`
+	Tags   []string `json:"tags"`
+	Limits struct {
+		MaxConns int `json:"max_conns"`
+	} `json:"limits"`
+}
+
+func TestStripJSONComments(t *testing.T) {
+	for input, want := range map[string]string{
+		"{\"a\": 1 // one\n}":           "{\"a\": 1       \n}",
+		"{/* a\nb */\"a\": 1}":          "{    \n    \"a\": 1}",
+		`{"url": "http://host/*x*/"}`:   `{"url": "http://host/*x*/"}`,
+		`{"a": "\" // x"}`:              `{"a": "\" // x"}`,
+		"{\"a\": [1, 2,\n], \"b\": 1,}": "{\"a\": [1, 2 \n], \"b\": 1 }",
+		`{"a": ",}"}`:                   `{"a": ",}"}`,
+		"{\"a\": 1} /* x":               "{\"a\": 1}     ",
+		"{\"a\": 1 // x":                "{\"a\": 1     ",
+	} {
+		// The offsets of the stripped data must match the input for the error positions
+		if got := string(stripJSONComments([]byte(input))); got != want {
+			t.Errorf("stripJSONComments(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestDecodeConfigData(t *testing.T) {
+	want := testFormatServer{Name: "api", Port: 8080, Debug: true, Ratio: 0.5, Tags: []string{"a", "b"}}
+	want.Limits.MaxConns = 16
+
+	inputs := map[ConfigFormat]string{
+		ConfigFormatJSON: `{"name": "api", "port": 8080, "debug": true, "ratio": 0.5, "tags": ["a", "b"], "limits": {"max_conns": 16}}`,
+		ConfigFormatJSONC: `{
+			// the name
+			"name": "api", "port": 8080, "debug": true, "ratio": 0.5,
+			"tags": ["a", "b",], /* nested */ "limits": {"max_conns": 16,},
+		}`,
+		ConfigFormatJSON5: `{
+			// the name
+			name: 'api', port: 0x1F90, debug: true, ratio: .5,
+			tags: ['a', "b",], /* nested */ limits: {max_conns: 16,},
+		}`,
+		ConfigFormatYAML: "base: &base\n  max_conns: 16\nname: api\nport: 8080\ndebug: true\nratio: 0.5\n" +
+			"tags: [a, b]\nlimits:\n  <<: *base\n",
+		ConfigFormatTOML: "name = \"api\"\nport = 8080\ndebug = true\nratio = 0.5\ntags = [\"a\", \"b\"]\n" +
+			"[limits]\nmax_conns = 16\n",
+		ConfigFormatINI: "; the server\nname = api ; inline comment\nport = 8080 # another one\ndebug: true\nratio = 0.5\n" +
+			"tags[] = a\ntags[] = 'b'\n\n[limits]\r\n# per process\r\nmax_conns = 16\r\n",
+	}
+	for format, input := range inputs {
+		var got testFormatServer
+		if err := DecodeConfigData(format, []byte(input), &got); err != nil {
+			t.Errorf("decoding %v: %v", format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("decoding %v got %+v, want %+v", format, got, want)
+		}
+	}
+}
+
+func TestDecodeINIConfigValues(t *testing.T) {
+	var got struct {
+		Version string `json:"version"`
+		URL     string `json:"url"`
+		App     struct {
+			HTTP struct {
+				Port int `json:"port"`
+			} `json:"http"`
+		} `json:"app"`
+	}
+	input := "version = \"1.0\"\nurl = http://host/#anchor ; the service\n[app.http]\nport = 80\n"
+	if err := DecodeConfigData(ConfigFormatINI, []byte(input), &got); err != nil {
+		t.Fatal(err)
+	}
+	// A quoted number stays a string, and a '#' without a space before it is a part of the value
+	if got.Version != "1.0" || got.URL != "http://host/#anchor" || got.App.HTTP.Port != 80 {
+		t.Errorf("got %+v", got)
+	}
+
+	for _, input := range []string{"port = 1\nport = 2\n", "app = 1\n[app]\nport = 2\n"} {
+		var decodeErr *ConfigDecodeError
+		if err := DecodeConfigData(ConfigFormatINI, []byte(input), &got); !errors.As(err, &decodeErr) || decodeErr.Line != 2 {
+			t.Errorf("decoding %q got the error %v, want an error at line 2", input, err)
+		}
+	}
+}
+
+func TestDecodeConfigDataErrorPositions(t *testing.T) {
+	tests := []struct {
+		format ConfigFormat
+		input  string
+		line   int
+		column int
+	}{
+		{ConfigFormatJSON, "{\n  \"name\": \"api\"\n  \"port\": 1\n}", 3, 4},
+		{ConfigFormatJSONC, "{\n  // \"port\": \"x\"\n  \"port\": \"8080\"\n}", 3, 17},
+		{ConfigFormatJSON5, "{\n  name: 'api',\n  port: 'x',\n}", 3, 12},
+		{ConfigFormatJSON5, "{\n  name: 'api'\n  port: 1\n}", 3, 4},
+		{ConfigFormatYAML, "name: api\nlimits:\n  max_conns: many\n", 3, 14},
+		{ConfigFormatYAML, "name: api\n  port: [", 2, 0},
+		// The key port of the limits table must not be taken for the port of the server
+		{ConfigFormatTOML, "name = \"api\"\n[limits]\nport = 1\nmax_conns = \"x\"\n[other]\nmax_conns = 1\n", 4, 14},
+		{ConfigFormatTOML, "[extra]\nport = 1\n[limits]\nMax_Conns = true\n", 4, 13},
+		{ConfigFormatTOML, "name = \"api\"\nport = \n", 2, 8},
+		{ConfigFormatINI, "name = api\n\n[limits]\nmax_conns = many ; comment\n", 4, 13},
+		{ConfigFormatINI, "name = api\n  tags = a\n", 2, 10},
+		{ConfigFormatINI, "name = api\nport\n", 2, 1},
+	}
+
+	for _, tt := range tests {
+		var server testFormatServer
+		err := DecodeConfigData(tt.format, []byte(tt.input), &server)
+		var decodeErr *ConfigDecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("decoding %v %q got the error %v, want a *ConfigDecodeError", tt.format, tt.input, err)
+			continue
+		}
+		if decodeErr.Format != tt.format || decodeErr.Line != tt.line || (tt.column > 0 && decodeErr.Column != tt.column) {
+			t.Errorf("decoding %v %q got the error at line %d, column %d, want line %d, column %d, %v",
+				tt.format, tt.input, decodeErr.Line, decodeErr.Column, tt.line, tt.column, err)
+		}
+	}
+}
+
+func TestGetConfigFormatByPath(t *testing.T) {
+	for filePath, want := range map[string]ConfigFormat{
+		"conf/app.json":  ConfigFormatJSON,
+		"conf/app.JSONC": ConfigFormatJSONC,
+		"conf/app.json5": ConfigFormatJSON5,
+		"conf/app.yml":   ConfigFormatYAML,
+		"conf/app.yaml":  ConfigFormatYAML,
+		"conf/app.toml":  ConfigFormatTOML,
+		"conf/app.ini":   ConfigFormatINI,
+		"conf/app.conf":  ConfigFormatJSON,
+		"conf/app":       ConfigFormatJSON,
+	} {
+		if got := GetConfigFormatByPath(filePath); got != want {
+			t.Errorf("GetConfigFormatByPath(%v) = %v, want %v", filePath, got, want)
+		}
+	}
+
+	if format, err := ParseConfigFormat("YML"); err != nil || format != ConfigFormatYAML {
+		t.Errorf("ParseConfigFormat(YML) = %v, %v", format, err)
+	}
+	if _, err := ParseConfigFormat("xml"); err == nil {
+		t.Error("the unsupported format xml has been accepted")
+	}
+}
@@ -0,0 +1,101 @@
+package frame
+
+import (
+	"fmt"
+)
+
+// IConfigDecoder is an optional interface of a configuration handler that supports a two-phase reload.
+// DecodeConfig decodes the data into a candidate without changing the current configuration, and CommitConfig
+// swaps the candidate in after it has been validated. The frame calls EncodeConfig on the handlers that do not
+// implement it, after the raw data has been validated.
+type IConfigDecoder interface {
+	DecodeConfig(data []byte) (interface{}, error)
+	CommitConfig(candidate interface{}) error
+}
+
+// IConfigValidator is an optional interface of a configuration handler that validates a candidate before
+// it is committed. The candidate is the result of DecodeConfig, or the raw data if the handler does not
+// implement IConfigDecoder.
+type IConfigValidator interface {
+	Validate(candidate interface{}) error
+}
+
+// ConfigValidator validates a candidate of a configuration before it is committed, see IConfigValidator.
+type ConfigValidator func(candidate interface{}) error
+
+// ConfigRejectedError means the content of a configuration is rejected, and the last good configuration is kept.
+type ConfigRejectedError struct {
+	Key   string
+	Stage string
+	Err   error
+}
+
+func (t *ConfigRejectedError) Error() string {
+	return fmt.Sprintf("the configuration %v is rejected at %v, %v", t.Key, t.Stage, t.Err)
+}
+
+func (t *ConfigRejectedError) Unwrap() error {
+	return t.Err
+}
+
+// RegisterConfigValidator adds a validator to the configuration of the key, it returns false if the key is not watched.
+func RegisterConfigValidator(key string, validator ConfigValidator) bool {
+	if validator == nil {
+		return false
+	}
+
+	configWatcherMgr.mutex.RLock()
+	watcher := configWatcherMgr.watcherMap[key]
+	configWatcherMgr.mutex.RUnlock()
+	if watcher == nil {
+		return false
+	}
+
+	watcher.mutex.Lock()
+	watcher.validators = append(watcher.validators, validator)
+	watcher.mutex.Unlock()
+	return true
+}
+
+func (t *ConfigWatcher) getValidators() []ConfigValidator {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.validators
+}
+
+// applyConfig decodes the data into a candidate, validates the candidate with the hook of the handler and
+// the registered validators, and commits it. A failure at any stage keeps the last good configuration.
+func (t *ConfigWatcher) applyConfig(data []byte) error {
+	decoder, twoPhase := t.confHandler.(IConfigDecoder)
+
+	var candidate interface{} = data
+	if twoPhase {
+		var decodeErr error
+		if candidate, decodeErr = decoder.DecodeConfig(data); decodeErr != nil {
+			return &ConfigRejectedError{Key: t.key, Stage: "decode", Err: decodeErr}
+		}
+	}
+
+	if validator, ok := t.confHandler.(IConfigValidator); ok {
+		if err := validator.Validate(candidate); err != nil {
+			return &ConfigRejectedError{Key: t.key, Stage: "validate", Err: err}
+		}
+	}
+	for _, validator := range t.getValidators() {
+		if err := validator(candidate); err != nil {
+			return &ConfigRejectedError{Key: t.key, Stage: "validate", Err: err}
+		}
+	}
+
+	var commitErr error
+	if twoPhase {
+		commitErr = decoder.CommitConfig(candidate)
+	} else {
+		commitErr = t.confHandler.EncodeConfig(data)
+	}
+	if commitErr != nil {
+		return &ConfigRejectedError{Key: t.key, Stage: "commit", Err: commitErr}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package frame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testReloadConfig struct {
+	Port int `json:"port"`
+}
+
+// testTwoPhaseHandler decodes the configuration into a candidate and rejects a port that is not positive.
+type testTwoPhaseHandler struct {
+	mutex   sync.Mutex
+	current *testReloadConfig
+}
+
+func (t *testTwoPhaseHandler) EncodeConfig(data []byte) error {
+	return errors.New("EncodeConfig is not called on a two-phase handler")
+}
+
+func (t *testTwoPhaseHandler) OnUpdate() {}
+
+func (t *testTwoPhaseHandler) GetConfigData() ([]byte, error) {
+	return json.Marshal(t.getPort())
+}
+
+func (t *testTwoPhaseHandler) DecodeConfig(data []byte) (interface{}, error) {
+	candidate := &testReloadConfig{}
+	if err := json.Unmarshal(data, candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (t *testTwoPhaseHandler) Validate(candidate interface{}) error {
+	if port := candidate.(*testReloadConfig).Port; port <= 0 {
+		return fmt.Errorf("invalid port %d", port)
+	}
+	return nil
+}
+
+func (t *testTwoPhaseHandler) CommitConfig(candidate interface{}) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.current = candidate.(*testReloadConfig)
+	return nil
+}
+
+func (t *testTwoPhaseHandler) getPort() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.current == nil {
+		return 0
+	}
+	return t.current.Port
+}
+
+func TestConfigReloadKeepsTheLastGoodConfig(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "reload.json")
+	handler := &testTwoPhaseHandler{}
+	watcher := &ConfigWatcher{}
+	watcher.initialize("test_reload", filePath, &ConfigRegInfo{
+		NewConfigHandlerFunc: func() IConfigHandler { return handler },
+		Validators: []ConfigValidator{func(candidate interface{}) error {
+			if candidate.(*testReloadConfig).Port == 80 {
+				return errors.New("port 80 is reserved")
+			}
+			return nil
+		}},
+	})
+
+	writeTestFile(t, filePath, `{"port": 8080}`)
+	if err := watcher.loadFileWithRetry(0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []struct {
+		data      string
+		wantStage string
+	}{
+		{`{"port": `, "decode"},
+		{`{"port": -1}`, "validate"},
+		{`{"port": 80}`, "validate"},
+	} {
+		writeTestFile(t, filePath, content.data)
+		err := watcher.loadFileWithRetry(0)
+		var rejectedErr *ConfigRejectedError
+		if !errors.As(err, &rejectedErr) || rejectedErr.Stage != content.wantStage || rejectedErr.Key != "test_reload" {
+			t.Fatalf("loading %s: got error %v, want a rejection at %v", content.data, err, content.wantStage)
+		}
+		if port := handler.getPort(); port != 8080 || watcher.GetVersion() != 1 {
+			t.Fatalf("loading %s replaced the last good config, got port %d in version %d", content.data, port, watcher.GetVersion())
+		}
+		if info := watcher.GetInfo(); info.LastError != err.Error() || info.LastErrorTimestamp == 0 {
+			t.Errorf("got the last error %q at %d, want %q", info.LastError, info.LastErrorTimestamp, err)
+		}
+	}
+
+	// A validator registered later is applied to the next load as well
+	configWatcherMgr.mutex.Lock()
+	configWatcherMgr.watcherMap["test_reload"] = watcher
+	configWatcherMgr.mutex.Unlock()
+	defer func() {
+		configWatcherMgr.mutex.Lock()
+		delete(configWatcherMgr.watcherMap, "test_reload")
+		configWatcherMgr.mutex.Unlock()
+	}()
+	if !RegisterConfigValidator("test_reload", func(candidate interface{}) error {
+		if candidate.(*testReloadConfig).Port > 65535 {
+			return errors.New("port out of range")
+		}
+		return nil
+	}) {
+		t.Fatal("registering a validator of a watched configuration failed")
+	}
+	if RegisterConfigValidator("test_reload_absent", func(interface{}) error { return nil }) {
+		t.Error("registering a validator of an unknown configuration succeeded")
+	}
+	writeTestFile(t, filePath, `{"port": 70000}`)
+	if err := watcher.loadFileWithRetry(0); err == nil {
+		t.Fatal("the registered validator has not been applied")
+	}
+
+	// The fixed content is committed even though it may equal content loaded before
+	writeTestFile(t, filePath, `{"port": 9090}`)
+	if err := watcher.loadFileWithRetry(0); err != nil {
+		t.Fatal(err)
+	}
+	if port := handler.getPort(); port != 9090 || watcher.GetVersion() != 2 {
+		t.Errorf("got port %d in version %d, want 9090 in version 2", port, watcher.GetVersion())
+	}
+	if info := watcher.GetInfo(); info.LastError != "" || info.LastErrorTimestamp != 0 {
+		t.Errorf("got the last error %q after a successful load, want none", info.LastError)
+	}
+}
+
+func TestConfigReloadPublishesRejections(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "rejected.json")
+	writeTestFile(t, filePath, `{"port": 80}`)
+	handler := &testTwoPhaseHandler{}
+	watcher := &ConfigWatcher{}
+	var validations int
+	watcher.initialize("test_rejected", filePath, &ConfigRegInfo{
+		NewConfigHandlerFunc: func() IConfigHandler { return handler },
+		Validators: []ConfigValidator{func(interface{}) error {
+			validations++
+			return errors.New("port 80 is reserved")
+		}},
+	})
+
+	events := make(chan ConfigEvent, 4)
+	for _, event := range []EventType{EventConfigRejected, EventConfigFailed} {
+		if err := SubscribeEventMessage(event, t.Name(), func(args ...interface{}) {
+			if e := args[0].(ConfigEvent); e.Key == "test_rejected" {
+				events <- e
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		UnsubscribeAll(t.Name())
+	})
+
+	// The content rejected by the validation is not retried, and is published only as a rejection
+	_ = watcher.loadFileWithRetry(reloadRetryTimes)
+	select {
+	case e := <-events:
+		var rejectedErr *ConfigRejectedError
+		if e.Type != EventConfigRejected || !errors.As(e.Err, &rejectedErr) || rejectedErr.Stage != "validate" {
+			t.Errorf("got event %+v, want a rejection", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the rejection has not been published")
+	}
+	if validations != 1 {
+		t.Errorf("the rejected content has been validated %d times, want 1", validations)
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		t.Fatal(err)
+	}
+	_ = watcher.loadFileWithRetry(0)
+	select {
+	case e := <-events:
+		if e.Type != EventConfigFailed {
+			t.Errorf("got event %+v, want a failure", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the failure has not been published")
+	}
+	select {
+	case e := <-events:
+		t.Errorf("got the extra event %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLauncherConfigHandlerRejectsUnorderedComponents(t *testing.T) {
+	handler := &launcherConfigHandler{}
+	for _, data := range []string{
+		`{"components": [{"id": "a", "component_type": "TestMgrStore"}, {"id": "a", "component_type": "TestMgrStore"}]}`,
+		`{"components": [{"id": "a", "component_type": "TestMgrStore", "depends_on": ["b"]},
+			{"id": "b", "component_type": "TestMgrStore", "depends_on": ["a"]}]}`,
+	} {
+		candidate, err := handler.DecodeConfig([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := handler.Validate(candidate); err == nil {
+			t.Errorf("the launcher configuration %s has been accepted", data)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+)
+
+// ConfigChange is the event published when a TypedConfig commits a new value, Old is nil on the first load.
+type ConfigChange[T any] struct {
+	Key string
+	Old *T
+	New *T
+}
+
+// TypedConfig is a configuration handler that decodes the file into a T and keeps the current value in an
+// atomic pointer, so that it can be read without locking. The value must be treated as read-only, a reload
+// replaces it with a new value. If *T has a method Validate() error, a candidate is validated before it is committed.
+type TypedConfig[T any] struct {
+	key string
+	// format is set before the first load and does not change afterwards
+	format  ConfigFormat
+	current atomic.Pointer[T]
+}
+
+func NewTypedConfig[T any](key string) *TypedConfig[T] {
+	return &TypedConfig[T]{key: key}
+}
+
+// RegisterTypedConfig registers the configuration of info.Key with a TypedConfig as its handler.
+func RegisterTypedConfig[T any](info ConfigRegInfo) *TypedConfig[T] {
+	typedConfig := NewTypedConfig[T](info.Key)
+	info.NewConfigHandlerFunc = func() IConfigHandler {
+		return typedConfig
+	}
+	RegisterConfigInfo(info)
+	return typedConfig
+}
+
+func (t *TypedConfig[T]) GetKey() string {
+	return t.key
+}
+
+// Get returns the current value, or nil if the configuration has not been loaded.
+func (t *TypedConfig[T]) Get() *T {
+	return t.current.Load()
+}
+
+// Subscribe calls the handler with the old and the new value whenever a new value is committed. The subscription
+// accepts the same options as the event subscriptions, and is cancelled with the other subscriptions of the subKey.
+func (t *TypedConfig[T]) Subscribe(subKey EventSubKey, handler func(old, new *T), opts ...SubscribeOption) (*Subscription, error) {
+	opts = append(opts[:len(opts):len(opts)], WithFilter(func(e ConfigChange[T]) bool {
+		return e.Key == t.key
+	}))
+	return Subscribe(subKey, func(_ context.Context, e ConfigChange[T]) {
+		handler(e.Old, e.New)
+	}, opts...)
+}
+
+func (t *TypedConfig[T]) SetConfigFormat(format ConfigFormat) {
+	t.format = format
+}
+
+func (t *TypedConfig[T]) DecodeConfig(data []byte) (interface{}, error) {
+	v := new(T)
+	if err := DecodeConfigData(t.format, data, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (t *TypedConfig[T]) Validate(candidate interface{}) error {
+	if validator, ok := candidate.(interface{ Validate() error }); ok {
+		return validator.Validate()
+	}
+	return nil
+}
+
+func (t *TypedConfig[T]) CommitConfig(candidate interface{}) error {
+	v := candidate.(*T)
+	old := t.current.Swap(v)
+	if err := publishLatest(ConfigChange[T]{Key: t.key, Old: old, New: v}); err != nil {
+		getLoggerInst().WarningF("Failed to notify the change of the configuration %v, %v", t.key, err)
+	}
+	return nil
+}
+
+func (t *TypedConfig[T]) EncodeConfig(data []byte) error {
+	candidate, err := t.DecodeConfig(data)
+	if err != nil {
+		return err
+	}
+	if err := t.Validate(candidate); err != nil {
+		return err
+	}
+	return t.CommitConfig(candidate)
+}
+
+func (t *TypedConfig[T]) OnUpdate() {
+
+}
+
+func (t *TypedConfig[T]) GetConfigData() ([]byte, error) {
+	v := t.Get()
+	if v == nil {
+		return []byte{}, nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,120 @@
+package frame
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testTypedConfig struct {
+	Name    string `json:"name"`
+	Workers int    `json:"workers"`
+}
+
+func (t *testTypedConfig) Validate() error {
+	if t.Workers <= 0 {
+		return errors.New("workers must be positive")
+	}
+	return nil
+}
+
+type testTypedChange struct {
+	old, new *testTypedConfig
+}
+
+func subscribeTestTypedConfig(t *testing.T, typedConfig *TypedConfig[testTypedConfig]) <-chan testTypedChange {
+	t.Helper()
+	changes := make(chan testTypedChange, 8)
+	subscription, err := typedConfig.Subscribe(t.Name(), func(old, new *testTypedConfig) {
+		changes <- testTypedChange{old: old, new: new}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		subscription.Cancel()
+	})
+	return changes
+}
+
+func waitTestTypedChange(t *testing.T, changes <-chan testTypedChange) testTypedChange {
+	t.Helper()
+	select {
+	case change := <-changes:
+		return change
+	case <-time.After(5 * time.Second):
+		t.Fatal("the change has not been delivered")
+	}
+	return testTypedChange{}
+}
+
+func TestTypedConfigCommitsValidValues(t *testing.T) {
+	typedConfig := NewTypedConfig[testTypedConfig]("test_typed")
+	changes := subscribeTestTypedConfig(t, typedConfig)
+	if typedConfig.Get() != nil {
+		t.Fatal("got a value before the first load")
+	}
+
+	if err := typedConfig.EncodeConfig([]byte(`{"name": "first", "workers": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	first := waitTestTypedChange(t, changes)
+	if first.old != nil || first.new.Name != "first" || typedConfig.Get() != first.new {
+		t.Fatalf("got the first change %+v -> %+v", first.old, first.new)
+	}
+
+	// Validate of *T rejects the candidate, and the current value is kept
+	if err := typedConfig.EncodeConfig([]byte(`{"name": "broken", "workers": 0}`)); err == nil {
+		t.Fatal("an invalid value has been committed")
+	}
+	if err := typedConfig.EncodeConfig([]byte(`{"name": `)); err == nil {
+		t.Fatal("an undecodable value has been committed")
+	}
+	if got := typedConfig.Get(); got.Name != "first" {
+		t.Fatalf("got %+v after the rejections, want the first value", got)
+	}
+
+	if err := typedConfig.EncodeConfig([]byte(`{"name": "second", "workers": 2}`)); err != nil {
+		t.Fatal(err)
+	}
+	second := waitTestTypedChange(t, changes)
+	if second.old != first.new || second.new.Name != "second" {
+		t.Errorf("got the second change %+v -> %+v", second.old, second.new)
+	}
+	if data, _ := typedConfig.GetConfigData(); string(data) != `{"name":"second","workers":2}` {
+		t.Errorf("got the config data %s", data)
+	}
+}
+
+func TestTypedConfigSubscriptionFiltersByKey(t *testing.T) {
+	first := NewTypedConfig[testTypedConfig]("test_typed_first")
+	second := NewTypedConfig[testTypedConfig]("test_typed_second")
+	changes := subscribeTestTypedConfig(t, first)
+
+	if err := second.EncodeConfig([]byte(`{"name": "second", "workers": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.EncodeConfig([]byte(`{"name": "first", "workers": 1}`)); err != nil {
+		t.Fatal(err)
+	}
+	// The change of the first configuration is the only one delivered
+	if change := waitTestTypedChange(t, changes); change.new.Name != "first" {
+		t.Errorf("got the change %+v of another configuration", change.new)
+	}
+}
+
+func TestRegisterTypedConfig(t *testing.T) {
+	const key = "test_typed_registered"
+	typedConfig := RegisterTypedConfig[testTypedConfig](ConfigRegInfo{Key: key, MustLoad: true})
+	t.Cleanup(func() {
+		delete(configRegInfoMap, key)
+	})
+
+	regInfo := configRegInfoMap[key]
+	if regInfo == nil || !regInfo.MustLoad || regInfo.NewConfigHandlerFunc() != typedConfig {
+		t.Fatalf("got the registration %+v", regInfo)
+	}
+	if _, ok := regInfo.NewConfigHandlerFunc().(IConfigDecoder); !ok {
+		t.Error("a TypedConfig is not loaded in two phases")
+	}
+}
@@ -2,18 +2,23 @@ package frame
 
 import (
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/akley-MK4/go-tools-box/ctime"
-	"github.com/fsnotify/fsnotify"
 )
 
 const (
 	defaultWaitConfInitDoneSec = 2
-	intervalRetryAddWatchSec   = 2
+	defaultDebounceIntervalMs  = 100
+	// A file that fails to load after a change may still be being written, retry before reporting the failure
+	reloadRetryTimes      = 3
+	reloadRetryIntervalMs = 100
 )
 
 type ConfigCallbackType = uint8
@@ -27,17 +32,25 @@ const (
 type NewConfigHandlerFunc func() IConfigHandler
 
 type ConfigRegInfo struct {
-	Key                   string
-	Suffix                string
+	Key    string
+	Suffix string
+	// Format is the format of the file, it is chosen by the file extension if it is empty
+	Format                ConfigFormat
 	NewConfigHandlerFunc  NewConfigHandlerFunc
 	MustLoad              bool
 	EnableWatchLog        bool
 	RetryWatchIntervalSec uint64
+	// DebounceIntervalMs is the settle window after the last event of the file before it is reloaded,
+	// defaultDebounceIntervalMs is used if it is 0
+	DebounceIntervalMs uint64
+	// Validators validate a candidate of the configuration before it is committed
+	Validators []ConfigValidator
 }
 
 var (
 	configWatcherMgr = &ConfigWatcherMgr{
-		watcherMap: make(map[string]*ConfigWatcher),
+		watcherMap:    make(map[string]*ConfigWatcher),
+		dirWatcherMap: make(map[string]*configDirWatcher),
 	}
 	configRegInfoMap = make(map[string]*ConfigRegInfo)
 )
@@ -57,29 +70,11 @@ func RegisterConfigCallback(cbType ConfigCallbackType, confHandler IConfigHandle
 		return false
 	}
 
-	for _, watcher := range configWatcherMgr.watcherMap {
+	for _, watcher := range configWatcherMgr.getWatcherList() {
 		if watcher.confHandler != confHandler {
 			continue
 		}
-
-		var cbList *[]ConfigCallback
-
-		switch cbType {
-		case ConfigCallbackTypeCreate:
-			cbList = &watcher.createTypeCallbacks
-			break
-		case ConfigCallbackTypeUpdate:
-			cbList = &watcher.updateTypeCallbacks
-			break
-		case ConfigCallbackTypeRemove:
-			cbList = &watcher.removeTypeCallbacks
-			break
-		default:
-			return false
-		}
-
-		*cbList = append(*cbList, f)
-		return true
+		return watcher.addCallback(cbType, f)
 	}
 
 	return false
@@ -96,11 +91,35 @@ type IConfigHandler interface {
 }
 
 type ConfigWatcherMgr struct {
-	watcherMap map[string]*ConfigWatcher
+	mutex         sync.RWMutex
+	watcherMap    map[string]*ConfigWatcher
+	dirWatcherMap map[string]*configDirWatcher
+	started       bool
 }
 
 func (t *ConfigWatcherMgr) initialize(workPath string, configInfoList []*configInfoModel, enabledDevMode bool) error {
+	watchers, registerErr := t.registerWatchers(workPath, configInfoList, enabledDevMode)
+	if registerErr != nil {
+		return registerErr
+	}
+
+	// The files are loaded without holding the mutex, so that the callbacks and the event handlers
+	// of the first load can call back into the manager
+	for _, watcher := range watchers {
+		if err := watcher.loadInitial(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *ConfigWatcherMgr) registerWatchers(workPath string, configInfoList []*configInfoModel,
+	enabledDevMode bool) (retWatchers []*ConfigWatcher, retErr error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.watcherMap = make(map[string]*ConfigWatcher)
+	t.dirWatcherMap = make(map[string]*configDirWatcher)
 
 	for _, info := range configInfoList {
 		regInfo := configRegInfoMap[info.Key]
@@ -109,10 +128,21 @@ func (t *ConfigWatcherMgr) initialize(workPath string, configInfoList []*configI
 			continue
 		}
 
+		format, parseFormatErr := ParseConfigFormat(info.Format)
+		if parseFormatErr != nil {
+			retErr = fmt.Errorf("invalid format of the configuration %v, %v", info.Key, parseFormatErr)
+			return
+		}
+		if format != "" {
+			regInfo.Format = format
+		}
+
 		if enabledDevMode {
 			fileName := info.Key
 			if regInfo.Suffix != "" {
 				fileName = fmt.Sprintf("%s.%s", info.Key, regInfo.Suffix)
+			} else if regInfo.Format != "" {
+				fileName = fmt.Sprintf("%s.%s", info.Key, regInfo.Format)
 			}
 			info.Path = path.Join(GetConfigTemplatePath(workPath), fileName)
 		}
@@ -122,36 +152,134 @@ func (t *ConfigWatcherMgr) initialize(workPath string, configInfoList []*configI
 		if info.RetryWatchIntervalSec > 0 {
 			regInfo.RetryWatchIntervalSec = info.RetryWatchIntervalSec
 		}
+		if info.DebounceIntervalMs > 0 {
+			regInfo.DebounceIntervalMs = info.DebounceIntervalMs
+		}
 
-		watcher := &ConfigWatcher{}
-		if err := watcher.initialize(info.Key, info.Path, regInfo); err != nil {
-			return fmt.Errorf("unable to initialize ConfigWatcher %v, Err: %v", info.Key, err)
+		watcher, err := t.registerWatcherLocked(info.Key, info.Path, regInfo)
+		if err != nil {
+			retErr = err
+			return
 		}
+		retWatchers = append(retWatchers, watcher)
+	}
+
+	return
+}
 
-		t.watcherMap[watcher.GetKey()] = watcher
+// addWatcher initializes and starts a ConfigWatcher for a file that is not listed in the launcher configuration.
+func (t *ConfigWatcherMgr) addWatcher(key, filePath string, regInfo *ConfigRegInfo) error {
+	t.mutex.Lock()
+	watcher, registerErr := t.registerWatcherLocked(key, filePath, regInfo)
+	t.mutex.Unlock()
+	if registerErr != nil {
+		return registerErr
 	}
 
+	if err := watcher.loadInitial(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.started {
+		watcher.dirWatcher.start()
+	}
 	return nil
 }
 
+// registerWatcherLocked initializes a ConfigWatcher and attaches it to the watcher of its directory,
+// the file is loaded by the caller after the mutex is released. The caller must hold the mutex.
+func (t *ConfigWatcherMgr) registerWatcherLocked(key, filePath string, regInfo *ConfigRegInfo) (*ConfigWatcher, error) {
+	if _, exist := t.watcherMap[key]; exist {
+		return nil, fmt.Errorf("ConfigWatcher %v already exists", key)
+	}
+
+	watcher := &ConfigWatcher{}
+	watcher.initialize(key, filePath, regInfo)
+
+	dirWatcher, exist := t.dirWatcherMap[watcher.dir]
+	if !exist {
+		var newErr error
+		dirWatcher, newErr = newConfigDirWatcher(watcher.dir, watcher.retryWatchIntervalSec)
+		if newErr != nil {
+			return nil, fmt.Errorf("unable to initialize ConfigWatcher %v, Err: %v", key, newErr)
+		}
+		if err := dirWatcher.watch(); err != nil {
+			if watcher.enableWatchLog {
+				getLoggerInst().WarningF("Unable to watch path %v for configuration %v, %v", watcher.dir, key, err)
+			}
+			if watcher.mustLoad {
+				_ = dirWatcher.stop()
+				return nil, fmt.Errorf("unable to initialize ConfigWatcher %v, Err: %v", key, err)
+			}
+		}
+		t.dirWatcherMap[watcher.dir] = dirWatcher
+	}
+	watcher.dirWatcher = dirWatcher
+	dirWatcher.addConfigWatcher(watcher)
+	t.watcherMap[key] = watcher
+	return watcher, nil
+}
+
 func (t *ConfigWatcherMgr) start() {
-	for _, watcher := range t.watcherMap {
-		if err := watcher.start(); err != nil {
-			getLoggerInst().WarningF("failed to start ConfigWatcher %v, Err: %v", watcher.GetKey(), err)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.started = true
+	for _, dirWatcher := range t.dirWatcherMap {
+		dirWatcher.start()
+	}
+}
+
+func (t *ConfigWatcherMgr) stop() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.started = false
+	var errs []error
+	for dir, dirWatcher := range t.dirWatcherMap {
+		if err := dirWatcher.stop(); err != nil {
+			getLoggerInst().WarningF("failed to stop the watcher of the configuration directory %v, Err: %v", dir, err)
+			errs = append(errs, fmt.Errorf("directory %v, %v", dir, err))
 		}
 	}
+
+	return errors.Join(errs...)
 }
 
-func (t *ConfigWatcherMgr) stop() {
+func (t *ConfigWatcherMgr) getWatcherList() (retList []*ConfigWatcher) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 	for _, watcher := range t.watcherMap {
-		if err := watcher.stop(); err != nil {
-			getLoggerInst().WarningF("failed to stop ConfigWatcher %v, Err: %v", watcher.GetKey(), err)
+		retList = append(retList, watcher)
+	}
+	return
+}
+
+// getLoadDurations returns how long the running loads have been running by the keys of the configurations.
+func (t *ConfigWatcherMgr) getLoadDurations() map[string]time.Duration {
+	retMap := make(map[string]time.Duration)
+	for _, watcher := range t.getWatcherList() {
+		if begin := watcher.loadBegin.Load(); begin != 0 {
+			retMap[watcher.GetKey()] = time.Since(time.Unix(0, begin))
+		}
+	}
+	return retMap
+}
+
+// getUnloadedMustLoadKeys returns the keys of the configurations that must be loaded but have not been loaded yet.
+func (t *ConfigWatcherMgr) getUnloadedMustLoadKeys() (retKeys []string) {
+	for _, watcher := range t.getWatcherList() {
+		if watcher.mustLoad && watcher.GetVersion() <= 0 {
+			retKeys = append(retKeys, watcher.GetKey())
 		}
 	}
+	return
 }
 
 func (t *ConfigWatcherMgr) GetConfigWatcherListInfo() (retList []ConfigWatcherInfo) {
-	for _, watcher := range t.watcherMap {
+	for _, watcher := range t.getWatcherList() {
 		retList = append(retList, watcher.GetInfo())
 	}
 
@@ -159,77 +287,145 @@ func (t *ConfigWatcherMgr) GetConfigWatcherListInfo() (retList []ConfigWatcherIn
 }
 
 type ConfigWatcher struct {
-	version               int
-	updateTimestamp       int64
 	key                   string
 	path                  string
 	dir                   string
 	fileName              string
-	hashVal               [md5.Size]byte
-	watched               bool
+	mustLoad              bool
 	enableWatchLog        bool
 	retryWatchIntervalSec uint64
-
-	watcher             *fsnotify.Watcher
-	confHandler         IConfigHandler
+	debounceInterval      time.Duration
+	format                ConfigFormat
+	dirWatcher            *configDirWatcher
+	confHandler           IConfigHandler
+
+	// pendingMutex guards the change waiting for the debounce window to settle
+	pendingMutex   sync.Mutex
+	pendingTimer   *time.Timer
+	pendingCreated bool
+	stopped        bool
+
+	// loadMutex serializes the loads of the file, loadBegin is the beginning of the running load in nanoseconds
+	loadMutex sync.Mutex
+	loadBegin atomic.Int64
+	// mutex guards the state of the loaded file and the callbacks
+	mutex               sync.RWMutex
+	version             int
+	updateTimestamp     int64
+	hashVal             [md5.Size]byte
+	lastError           error
+	lastErrorTimestamp  int64
+	validators          []ConfigValidator
 	updateTypeCallbacks []ConfigCallback
 	createTypeCallbacks []ConfigCallback
 	removeTypeCallbacks []ConfigCallback
 }
 
-func (t *ConfigWatcher) initialize(key, filePath string, regInfo *ConfigRegInfo) error {
+func (t *ConfigWatcher) initialize(key, filePath string, regInfo *ConfigRegInfo) {
 	t.key = key
 	t.dir, t.fileName = path.Split(filePath)
+	t.dir = path.Clean(t.dir)
 	t.path = path.Join(t.dir, t.fileName)
+	t.format = resolveConfigFormat(regInfo.Format, t.fileName)
 	t.confHandler = regInfo.NewConfigHandlerFunc()
-
-	w, newWatcherErr := fsnotify.NewWatcher()
-	if newWatcherErr != nil {
-		return fmt.Errorf("failed to create watcher, %v", newWatcherErr)
+	if formatHandler, ok := t.confHandler.(IConfigFormatHandler); ok {
+		formatHandler.SetConfigFormat(t.format)
 	}
-	t.watcher = w
+	t.mustLoad = regInfo.MustLoad
+	t.validators = append(t.validators, regInfo.Validators...)
 	t.enableWatchLog = regInfo.EnableWatchLog
 	t.retryWatchIntervalSec = regInfo.RetryWatchIntervalSec
 	if t.retryWatchIntervalSec <= 0 {
 		t.retryWatchIntervalSec = defaultWaitConfInitDoneSec
 	}
-
-	if err := w.Add(t.dir); err != nil {
-		if t.enableWatchLog {
-			getLoggerInst().WarningF("Unable to watch path %v for configuration %v, %v", t.dir, t.key, err)
-		}
-		if regInfo.MustLoad {
-			return err
-		}
-
-		go func() {
-			t.intervalRetryWatchPath(t.dir)
-		}()
-		return nil
+	t.debounceInterval = time.Millisecond * time.Duration(regInfo.DebounceIntervalMs)
+	if t.debounceInterval <= 0 {
+		t.debounceInterval = time.Millisecond * defaultDebounceIntervalMs
 	}
-	t.watched = true
+}
 
+// loadInitial loads the file for the first time, a failure is an error only if the configuration must be loaded.
+func (t *ConfigWatcher) loadInitial() error {
 	loadErr := t.loadFiled()
 	if loadErr != nil && t.enableWatchLog {
 		getLoggerInst().WarningF("Failed to load configuration %v from path %s, %v", t.key, t.path, loadErr)
 	}
-	if regInfo.MustLoad && loadErr != nil {
-		return loadErr
+	if t.mustLoad && loadErr != nil {
+		return fmt.Errorf("unable to initialize ConfigWatcher %v, Err: %v", t.key, loadErr)
 	}
-
 	return nil
 }
 
-func (t *ConfigWatcher) start() error {
-	go t.loopWatch()
-	return nil
+func (t *ConfigWatcher) addCallback(cbType ConfigCallbackType, f ConfigCallback) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch cbType {
+	case ConfigCallbackTypeCreate:
+		t.createTypeCallbacks = append(t.createTypeCallbacks, f)
+	case ConfigCallbackTypeUpdate:
+		t.updateTypeCallbacks = append(t.updateTypeCallbacks, f)
+	case ConfigCallbackTypeRemove:
+		t.removeTypeCallbacks = append(t.removeTypeCallbacks, f)
+	default:
+		return false
+	}
+	return true
 }
 
-func (t *ConfigWatcher) stop() error {
-	return t.watcher.Close()
+func (t *ConfigWatcher) getCallbacks(cbType ConfigCallbackType) []ConfigCallback {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	switch cbType {
+	case ConfigCallbackTypeCreate:
+		return t.createTypeCallbacks
+	case ConfigCallbackTypeUpdate:
+		return t.updateTypeCallbacks
+	case ConfigCallbackTypeRemove:
+		return t.removeTypeCallbacks
+	}
+	return nil
 }
 
 func (t *ConfigWatcher) loadFiled() error {
+	return t.loadFileWithRetry(0)
+}
+
+// loadFileWithRetry loads the file, and retries the load up to retryTimes if it fails. A content rejected by the
+// validation is not retried, since it fails the same way until the file is changed. Only the last failure is reported.
+func (t *ConfigWatcher) loadFileWithRetry(retryTimes int) (retErr error) {
+	t.loadMutex.Lock()
+	defer t.loadMutex.Unlock()
+	t.loadBegin.Store(time.Now().UnixNano())
+	defer t.loadBegin.Store(0)
+
+	defer func() {
+		if retErr != nil {
+			t.recordFailure(retErr)
+		}
+	}()
+
+	for retryTotal := 0; ; retryTotal++ {
+		retErr = t.loadFileOnce()
+		if retErr == nil || retryTotal >= retryTimes {
+			return
+		}
+		var rejectedErr *ConfigRejectedError
+		if errors.As(retErr, &rejectedErr) && rejectedErr.Stage == "validate" {
+			return
+		}
+		if t.enableWatchLog {
+			getLoggerInst().DebugF("Failed to load configuration %v from path %s, RetryTotal: %d, Err: %v",
+				t.key, t.path, retryTotal, retErr)
+		}
+		time.Sleep(time.Millisecond * reloadRetryIntervalMs)
+	}
+}
+
+// loadFileOnce reads the file and applies it to the configuration handler if its content has changed,
+// the caller must hold the loadMutex.
+func (t *ConfigWatcher) loadFileOnce() error {
 	data, readErr := os.ReadFile(t.path)
 	if readErr != nil {
 		return readErr
@@ -239,118 +435,149 @@ func (t *ConfigWatcher) loadFiled() error {
 	}
 
 	hashVal := md5.Sum(data)
-	if hashVal == t.hashVal {
-		//logger.DebugFmt("The config content has not changed, and the config will not be updated, "+
-		//	"Path: %s",
-		//	t.path)
+	t.mutex.Lock()
+	unchanged := hashVal == t.hashVal
+	if unchanged {
+		// The file is back to the committed content, so the failure of a load in between is resolved
+		t.lastError = nil
+		t.lastErrorTimestamp = 0
+	}
+	t.mutex.Unlock()
+	if unchanged {
 		return nil
 	}
 
-	t.hashVal = hashVal
-	if err := t.confHandler.EncodeConfig(data); err != nil {
+	// The hash is stored only after the content is committed, so that rejected content is loaded again once it is fixed
+	if err := t.applyConfig(data); err != nil {
 		return err
 	}
+
+	t.mutex.Lock()
+	t.hashVal = hashVal
 	t.version += 1
 	t.updateTimestamp = ctime.CurrentTimestamp()
+	t.lastError = nil
+	t.lastErrorTimestamp = 0
+	version := t.version
+	t.mutex.Unlock()
+
+	configReloadCounter.WithLabelValues(t.key).Inc()
+	configVersionGauge.WithLabelValues(t.key).Set(float64(version))
 	if t.enableWatchLog {
-		getLoggerInst().InfoF("The configuration %v has been updated from path %s, and the content in version %v is as follows", t.key, t.path, t.version)
+		getLoggerInst().InfoF("The configuration %v has been updated from path %s, and the content in version %v is as follows", t.key, t.path, version)
 		fmt.Println(string(data))
 	} else {
-		getLoggerInst().InfoF("The configuration %v has been updated from path %s, and the version is %v", t.key, t.path, t.version)
+		getLoggerInst().InfoF("The configuration %v has been updated from path %s, and the version is %v", t.key, t.path, version)
 	}
 
-	updateCallbacks := t.updateTypeCallbacks
-	for _, f := range updateCallbacks {
+	for _, f := range t.getCallbacks(ConfigCallbackTypeUpdate) {
 		f()
 	}
 
+	event := EventConfigUpdated
+	if version == 1 {
+		event = EventConfigLoaded
+	}
+	publishFrameEvent(event, ConfigEvent{Type: event, Key: t.key, Path: t.path, Version: version})
+
 	return nil
 }
 
-func (t *ConfigWatcher) loopWatch() {
-	if !t.watched {
-		getLoggerInst().InfoF("The configuration %v dose not watch successfully, start timing check operation", t.key)
-
-		for {
-			if t.enableWatchLog {
-				getLoggerInst().DebugF("The configuration %v dose not watch successfully. "+
-					"Wait for %d seconds before check and watch the config", t.key, t.retryWatchIntervalSec)
-			}
-			time.Sleep(time.Second * time.Duration(t.retryWatchIntervalSec))
-			if t.watched {
-				break
-			}
-		}
-		if t.enableWatchLog {
-			getLoggerInst().InfoF("The configuration %v exits the timed check operation", t.key)
-		}
-
-		if err := t.loadFiled(); err != nil {
-			//return err
-			getLoggerInst().WarningF("Failed to load configuration %v, %v", t.key, err)
-		}
+// recordFailure keeps the failure of the last load in the information of the ConfigWatcher until a load succeeds,
+// and publishes EventConfigRejected for a rejected content or EventConfigFailed for any other failure.
+func (t *ConfigWatcher) recordFailure(err error) {
+	t.mutex.Lock()
+	t.lastError = err
+	t.lastErrorTimestamp = ctime.CurrentTimestamp()
+	version := t.version
+	t.mutex.Unlock()
+
+	configReloadFailureCounter.WithLabelValues(t.key).Inc()
+	var rejectedErr *ConfigRejectedError
+	event := EventConfigFailed
+	if errors.As(err, &rejectedErr) {
+		event = EventConfigRejected
 	}
+	publishFrameEvent(event, ConfigEvent{Type: event, Key: t.key, Path: t.path, Version: version, Err: err})
+}
 
-	for {
-		if t.watch() {
-			break
-		}
+func (t *ConfigWatcher) reload() {
+	if err := t.loadFileWithRetry(reloadRetryTimes); err != nil {
+		getLoggerInst().WarningF("Failed to load configuration %v from path %s, %v", t.key, t.path, err)
 	}
+}
 
-	getLoggerInst().InfoF("ConfigWatcher.loopWatch quit, Key: %v, Path: %v", t.key, t.path)
+// scheduleChange records a change of the file and applies it once no further change arrives within the
+// debounce window, so that the burst of events of a single save results in one reload of the final content.
+func (t *ConfigWatcher) scheduleChange(created bool) {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	if t.stopped {
+		return
+	}
+	t.pendingCreated = t.pendingCreated || created
+	if t.pendingTimer == nil {
+		t.pendingTimer = time.AfterFunc(t.debounceInterval, t.applyPendingChange)
+		return
+	}
+	t.pendingTimer.Reset(t.debounceInterval)
 }
 
-func (t *ConfigWatcher) watch() bool {
-	select {
-	case err, ok := <-t.watcher.Errors:
-		if !ok {
-			getLoggerInst().Warning("watcher.Errors not ok")
-			return true
-		}
-		getLoggerInst().WarningF("ConfigWatcher %v has failed to watch, Err: %v", t.key, err)
-	case e, ok := <-t.watcher.Events:
-		if !ok {
-			getLoggerInst().Warning("watcher.Events not ok")
-			return true
-		}
+// applyPendingChange applies the settled change. An editor may save the file atomically by writing a temporary
+// file and renaming it over the file, so the file is treated as removed only if it no longer exists.
+func (t *ConfigWatcher) applyPendingChange() {
+	t.pendingMutex.Lock()
+	created := t.pendingCreated
+	t.pendingCreated = false
+	t.pendingTimer = nil
+	t.pendingMutex.Unlock()
+
+	if _, err := os.Stat(t.path); err != nil {
+		t.handleRemoved()
+		return
+	}
 
-		//logger.WarningF("The config file of path %s has changed, op: %v", e.Name, e.Op)
-		needLoad := true
-		var cbs []ConfigCallback
-		switch e.Op {
-		case fsnotify.Create:
-			cbs = t.createTypeCallbacks
-		case fsnotify.Remove:
-			cbs = t.removeTypeCallbacks
-			needLoad = false
-		case fsnotify.Rename:
-			return false
-		}
-		if len(cbs) > 0 || !needLoad {
-			for _, cb := range cbs {
-				cb()
-			}
-			return false
+	t.reload()
+	if created {
+		for _, cb := range t.getCallbacks(ConfigCallbackTypeCreate) {
+			cb()
 		}
+	}
+}
 
-		//if e.Op&fsnotify.Write != fsnotify.Write && e.Op&fsnotify.Chmod != fsnotify.Chmod {
-		//	break
-		//}
-
-		//idx := strings.LastIndex(e.Name, t.fileName)
-		//if (idx + len(t.fileName)) != len(e.Name) {
-		//	break
-		//}
+// stopPendingChange discards the change waiting for the debounce window, and ignores the subsequent changes.
+func (t *ConfigWatcher) stopPendingChange() {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
 
-		if err := t.loadFiled(); err != nil {
-			getLoggerInst().WarningF("Failed to load configuration %v from path %s, %v", t.key, t.path, err)
-		}
+	t.stopped = true
+	if t.pendingTimer != nil {
+		t.pendingTimer.Stop()
+		t.pendingTimer = nil
 	}
+}
 
-	return false
+func (t *ConfigWatcher) handleRemoved() {
+	// Forget the content so that the file is loaded again when it is created with the same content
+	t.mutex.Lock()
+	t.hashVal = [md5.Size]byte{}
+	version := t.version
+	t.mutex.Unlock()
+
+	if t.enableWatchLog {
+		getLoggerInst().InfoF("The file %s of the configuration %v has been removed", t.path, t.key)
+	}
+	for _, cb := range t.getCallbacks(ConfigCallbackTypeRemove) {
+		cb()
+	}
+	publishFrameEvent(EventConfigRemoved,
+		ConfigEvent{Type: EventConfigRemoved, Key: t.key, Path: t.path, Version: version})
 }
 
 func (t *ConfigWatcher) GetVersion() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
 	return t.version
 }
 
@@ -362,25 +589,6 @@ func (t *ConfigWatcher) GetPath() string {
 	return t.path
 }
 
-func (t *ConfigWatcher) intervalRetryWatchPath(dirPath string) {
-	var retryTotal int
-	for {
-		time.Sleep(time.Second * time.Duration(t.retryWatchIntervalSec))
-		retryTotal += 1
-		if watchErr := t.watcher.Add(dirPath); watchErr != nil {
-			if t.enableWatchLog {
-				getLoggerInst().WarningF("Failed to watch path %s, Key: %s, RetryTotal: %d, Err: %v",
-					dirPath, t.key, retryTotal, watchErr)
-			}
-			continue
-		}
-
-		t.watched = true
-		getLoggerInst().InfoF("Watched path %s, Key: %s, RetryTotal: %d", dirPath, t.key, retryTotal)
-		return
-	}
-}
-
 type ConfigWatcherInfo struct {
 	Version         int
 	UpdateTimestamp int64
@@ -388,25 +596,33 @@ type ConfigWatcherInfo struct {
 	Path            string
 	Dir             string
 	FileName        string
+	Format          ConfigFormat
 	//HashVal         string
 	Watched    bool
+	MustLoad   bool
 	ConfigData string
+	// LastError is the failure of the last load if no load has succeeded since, the current configuration is the last good one
+	LastError          string
+	LastErrorTimestamp int64
 }
 
 func (t *ConfigWatcher) GetInfo() (retInfo ConfigWatcherInfo) {
-	hashVal := make([]byte, 0, md5.Size)
-	for _, v := range t.hashVal {
-		hashVal = append(hashVal, v)
-	}
-
+	t.mutex.RLock()
 	retInfo.Version = t.version
 	retInfo.UpdateTimestamp = t.updateTimestamp
+	if t.lastError != nil {
+		retInfo.LastError = t.lastError.Error()
+		retInfo.LastErrorTimestamp = t.lastErrorTimestamp
+	}
+	t.mutex.RUnlock()
 	retInfo.Key = t.key
 	retInfo.Path = t.path
 	retInfo.Dir = t.dir
 	retInfo.FileName = t.fileName
+	retInfo.Format = t.format
 	//retInfo.HashVal = string(hashVal)
-	retInfo.Watched = t.watched
+	retInfo.Watched = t.dirWatcher != nil && t.dirWatcher.isWatched()
+	retInfo.MustLoad = t.mustLoad
 
 	if t.confHandler == nil {
 		return
@@ -0,0 +1,243 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConfigHandler keeps the data of the last load, onEncode is called while the file is loaded and
+// its error fails the load.
+type testConfigHandler struct {
+	mutex    sync.Mutex
+	data     string
+	encoded  int
+	onEncode func() error
+}
+
+func (t *testConfigHandler) EncodeConfig(data []byte) error {
+	if t.onEncode != nil {
+		if err := t.onEncode(); err != nil {
+			return err
+		}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.data = string(data)
+	t.encoded++
+	return nil
+}
+
+func (t *testConfigHandler) OnUpdate() {}
+
+func (t *testConfigHandler) GetConfigData() ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return []byte(t.data), nil
+}
+
+func registerTestConfig(t *testing.T, key string, handler *testConfigHandler) {
+	t.Helper()
+	RegisterConfigInfo(ConfigRegInfo{
+		Key:                  key,
+		MustLoad:             true,
+		NewConfigHandlerFunc: func() IConfigHandler { return handler },
+	})
+	t.Cleanup(func() {
+		delete(configRegInfoMap, key)
+	})
+}
+
+// newTestConfigWatcherMgr initializes and starts a manager of the configurations, which is stopped when the test ends.
+func newTestConfigWatcherMgr(t *testing.T, configInfoList ...*configInfoModel) *ConfigWatcherMgr {
+	t.Helper()
+	mgr := &ConfigWatcherMgr{}
+	if err := mgr.initialize("", configInfoList, false); err != nil {
+		t.Fatal(err)
+	}
+	mgr.start()
+	t.Cleanup(func() {
+		_ = mgr.stop()
+	})
+	return mgr
+}
+
+func waitTestConfigData(t *testing.T, handler *testConfigHandler, want string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, _ := handler.GetConfigData(); string(data) == want {
+			return
+		}
+	}
+	data, _ := handler.GetConfigData()
+	t.Fatalf("got the configuration %q, want %q", data, want)
+}
+
+func writeTestFile(t *testing.T, filePath, data string) {
+	t.Helper()
+	if err := os.WriteFile(filePath, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigWatcherInitializeAllowsHandlersToCallTheManager(t *testing.T) {
+	const key = "test_watcher_reentrant"
+	filePath := path.Join(t.TempDir(), key+".json")
+	writeTestFile(t, filePath, `{"name": "first"}`)
+
+	// The handler reaches the manager through the global, so swap a fresh one in while initialize runs
+	mgr := &ConfigWatcherMgr{}
+	prevMgr := configWatcherMgr
+	configWatcherMgr = mgr
+	defer func() {
+		configWatcherMgr = prevMgr
+	}()
+
+	var infoList []ConfigWatcherInfo
+	handler := &testConfigHandler{onEncode: func() error {
+		infoList = GetConfigWatcherMgr().GetConfigWatcherListInfo()
+		return nil
+	}}
+	registerTestConfig(t, key, handler)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mgr.initialize("", []*configInfoModel{{Key: key, Path: filePath}}, false)
+	}()
+
+	select {
+	case err := <-done:
+		defer mgr.stop()
+		if err != nil {
+			t.Fatalf("initialize: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("initialize deadlocked in a handler calling the manager")
+	}
+
+	if len(infoList) != 1 || infoList[0].Key != key {
+		t.Errorf("got watcher info %+v, want the watcher of %v", infoList, key)
+	}
+}
+
+func TestConfigWatchersShareTheWatcherOfTheirDirectory(t *testing.T) {
+	dir := t.TempDir()
+	first, second := &testConfigHandler{}, &testConfigHandler{}
+	registerTestConfig(t, "test_dir_first", first)
+	registerTestConfig(t, "test_dir_second", second)
+	firstPath, secondPath := path.Join(dir, "first.json"), path.Join(dir, "second.json")
+	writeTestFile(t, firstPath, "first 1")
+	writeTestFile(t, secondPath, "second 1")
+
+	mgr := newTestConfigWatcherMgr(t,
+		&configInfoModel{Key: "test_dir_first", Path: firstPath},
+		&configInfoModel{Key: "test_dir_second", Path: secondPath},
+	)
+	if len(mgr.dirWatcherMap) != 1 {
+		t.Fatalf("got %d directory watchers, want 1", len(mgr.dirWatcherMap))
+	}
+
+	// An editor saves the file atomically by renaming a temporary file over it
+	tmpPath := path.Join(dir, ".first.json.swp")
+	writeTestFile(t, tmpPath, "first 2")
+	if err := os.Rename(tmpPath, firstPath); err != nil {
+		t.Fatal(err)
+	}
+	waitTestConfigData(t, first, "first 2")
+
+	writeTestFile(t, secondPath, "second 2")
+	waitTestConfigData(t, second, "second 2")
+	if data, _ := first.GetConfigData(); string(data) != "first 2" {
+		t.Errorf("the change of another file reloaded the configuration as %q", data)
+	}
+}
+
+func TestConfigWatcherReloadsOnConfigMapSwap(t *testing.T) {
+	// A ConfigMap volume links the files through the '..data' symlink to a timestamped directory
+	dir := t.TempDir()
+	mkdirTestConfigMap := func(name, data string) {
+		if err := os.Mkdir(path.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, path.Join(dir, name, "app.json"), data)
+	}
+	mkdirTestConfigMap("..2026_01_01", "v1")
+	if err := os.Symlink("..2026_01_01", path.Join(dir, k8sConfigMapDataDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(path.Join(k8sConfigMapDataDir, "app.json"), path.Join(dir, "app.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &testConfigHandler{}
+	registerTestConfig(t, "test_config_map", handler)
+	newTestConfigWatcherMgr(t, &configInfoModel{Key: "test_config_map", Path: path.Join(dir, "app.json")})
+	waitTestConfigData(t, handler, "v1")
+
+	mkdirTestConfigMap("..2026_01_02", "v2")
+	if err := os.Symlink("..2026_01_02", path.Join(dir, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path.Join(dir, "..data_tmp"), path.Join(dir, k8sConfigMapDataDir)); err != nil {
+		t.Fatal(err)
+	}
+	waitTestConfigData(t, handler, "v2")
+}
+
+func TestConfigWatcherDebouncesBurstOfEvents(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "burst.json")
+	writeTestFile(t, filePath, "0")
+	handler := &testConfigHandler{}
+	registerTestConfig(t, "test_debounce", handler)
+	configInfo := &configInfoModel{Key: "test_debounce", Path: filePath, DebounceIntervalMs: 200}
+	mgr := newTestConfigWatcherMgr(t, configInfo)
+
+	for idx := 1; idx <= 5; idx++ {
+		writeTestFile(t, filePath, fmt.Sprint(idx))
+	}
+	waitTestConfigData(t, handler, "5")
+	// Wait out another debounce window in case a second reload was scheduled
+	time.Sleep(300 * time.Millisecond)
+
+	handler.mutex.Lock()
+	encoded := handler.encoded
+	handler.mutex.Unlock()
+	if encoded != 2 {
+		t.Errorf("the configuration has been loaded %d times, want the initial load and one reload", encoded)
+	}
+	if version := mgr.watcherMap["test_debounce"].GetVersion(); version != 2 {
+		t.Errorf("got version %d, want 2", version)
+	}
+}
+
+func TestConfigWatcherRetriesFailedReload(t *testing.T) {
+	filePath := path.Join(t.TempDir(), "retry.json")
+	writeTestFile(t, filePath, "complete")
+
+	// The handler fails twice, as it would on a file that is still being written
+	var failures int
+	handler := &testConfigHandler{onEncode: func() error {
+		if failures < 2 {
+			failures++
+			return errors.New("partial file")
+		}
+		return nil
+	}}
+	watcher := &ConfigWatcher{}
+	watcher.initialize("test_retry", filePath, &ConfigRegInfo{NewConfigHandlerFunc: func() IConfigHandler { return handler }})
+
+	if err := watcher.loadFileWithRetry(0); err == nil {
+		t.Fatal("a load without retries succeeded on the first failure")
+	}
+	failures = 0
+	if err := watcher.loadFileWithRetry(reloadRetryTimes); err != nil {
+		t.Fatalf("the load failed after retrying, %v", err)
+	}
+	if data, _ := handler.GetConfigData(); string(data) != "complete" || watcher.GetVersion() != 1 {
+		t.Errorf("got the configuration %q in version %d", data, watcher.GetVersion())
+	}
+}
@@ -0,0 +1,246 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var eventBusInst = &eventBus{
+	keySubscriptions: make(map[EventSubKey][]*Subscription),
+}
+
+// eventBus dispatches events to the handlers subscribed to their topics. A typed event is keyed by the
+// reflect.Type of its payload, and a legacy event message is keyed by its EventType.
+type eventBus struct {
+	topics sync.Map
+
+	mutex sync.Mutex
+	// keySubscriptions holds the active subscriptions of each subscriber key
+	keySubscriptions map[EventSubKey][]*Subscription
+}
+
+// getEventTopic returns the topic of the key, the topic is created on the first call.
+func getEventTopic[E any](key interface{}, name string) *eventTopic[E] {
+	if v, ok := eventBusInst.topics.Load(key); ok {
+		return v.(*eventTopic[E])
+	}
+
+	v, _ := eventBusInst.topics.LoadOrStore(key, newEventTopic[E](key, name))
+	return v.(*eventTopic[E])
+}
+
+func getTypedEventTopic[E any]() *eventTopic[E] {
+	tpy := reflect.TypeFor[E]()
+	return getEventTopic[E](tpy, tpy.String())
+}
+
+func (t *eventBus) addSubscription(sub *Subscription) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.keySubscriptions[sub.key] = append(t.keySubscriptions[sub.key], sub)
+}
+
+func (t *eventBus) removeSubscription(sub *Subscription) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	subs := t.keySubscriptions[sub.key]
+	for idx, s := range subs {
+		if s == sub {
+			subs = append(subs[:idx:idx], subs[idx+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(t.keySubscriptions, sub.key)
+		return
+	}
+	t.keySubscriptions[sub.key] = subs
+}
+
+func (t *eventBus) getSubscriptions(key EventSubKey) []*Subscription {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]*Subscription(nil), t.keySubscriptions[key]...)
+}
+
+// eventTopic holds the subscribers of a topic as a copy-on-write slice sorted by priority, so that publishing
+// reads the subscribers without locking and without allocating.
+type eventTopic[E any] struct {
+	key         interface{}
+	name        string
+	mutex       sync.Mutex
+	subscribers atomic.Pointer[[]*eventSubscriber[E]]
+
+	// retainEnabled is read without the mutex on publishing, retainSize and retained are guarded by the mutex
+	retainEnabled atomic.Bool
+	retainSize    int
+	retained      []E
+
+	publishCounter    *Counter
+	durationHistogram *Histogram
+}
+
+func newEventTopic[E any](key interface{}, name string) *eventTopic[E] {
+	return &eventTopic[E]{
+		key:               key,
+		name:              name,
+		publishCounter:    eventPublishCounter.WithLabelValues(name),
+		durationHistogram: eventHandlerDurationHistogram.WithLabelValues(name),
+	}
+}
+
+func (t *eventTopic[E]) loadSubscribers() []*eventSubscriber[E] {
+	if p := t.subscribers.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+func (t *eventTopic[E]) subscribe(key EventSubKey, handle func(ctx context.Context, e E), opts ...SubscribeOption) (*Subscription, error) {
+	if handle == nil {
+		return nil, fmt.Errorf("the handler subscribed to event %v is nil", t.name)
+	}
+
+	subOpts := newSubscribeOptions(opts)
+	if subOpts.mode == DeliveryPool && subOpts.overflow == OverflowDropOldest {
+		return nil, fmt.Errorf("the overflow policy %v is not supported by the pool delivery of event %v", subOpts.overflow, t.name)
+	}
+	var filters []func(E) bool
+	for _, filter := range subOpts.filters {
+		f, ok := filter.(func(E) bool)
+		if !ok {
+			return nil, fmt.Errorf("the filter %T does not match event %v", filter, t.name)
+		}
+		filters = append(filters, f)
+	}
+
+	sub := newEventSubscriber(t, key, handle, subOpts)
+	sub.filters = filters
+	sub.subscription = &Subscription{key: key, topicKey: t.key, topicName: t.name}
+	sub.subscription.cancel = func() {
+		t.unsubscribe(sub)
+	}
+
+	// Register the subscription before it can receive events, a one-shot subscription may cancel itself at once
+	eventBusInst.addSubscription(sub.subscription)
+
+	t.mutex.Lock()
+	// The events published once the subscriber is stored are held until the retained events have been replayed
+	var replayEvents []E
+	if !subOpts.noReplay {
+		replayEvents = append(replayEvents, t.retained...)
+	}
+	sub.replaying.Store(len(replayEvents) > 0)
+	oldSubscribers := t.loadSubscribers()
+	newSubscribers := make([]*eventSubscriber[E], 0, len(oldSubscribers)+1)
+	newSubscribers = append(newSubscribers, oldSubscribers...)
+	newSubscribers = append(newSubscribers, sub)
+	// Handlers with a higher priority are called first, and handlers with the same priority in the order of subscription
+	sort.SliceStable(newSubscribers, func(i, j int) bool {
+		return newSubscribers[i].priority > newSubscribers[j].priority
+	})
+	t.subscribers.Store(&newSubscribers)
+	t.mutex.Unlock()
+
+	if len(replayEvents) > 0 {
+		t.replay(sub, replayEvents)
+	}
+	return sub.subscription, nil
+}
+
+func (t *eventTopic[E]) unsubscribe(sub *eventSubscriber[E]) {
+	t.mutex.Lock()
+	oldSubscribers := t.loadSubscribers()
+	newSubscribers := make([]*eventSubscriber[E], 0, len(oldSubscribers))
+	for _, s := range oldSubscribers {
+		if s != sub {
+			newSubscribers = append(newSubscribers, s)
+		}
+	}
+	t.subscribers.Store(&newSubscribers)
+	t.mutex.Unlock()
+
+	sub.stop()
+	eventBusInst.removeSubscription(sub.subscription)
+}
+
+// publish delivers the event to the subscribers in the order of priority. The panics of synchronous
+// handlers are recovered and returned as an error, and they do not prevent the other handlers from receiving the event.
+// The events published by the frame are coalesced rather than blocking the publisher on a full queue, see
+// eventSubscriber.deliverLatest.
+func (t *eventTopic[E]) publish(ctx context.Context, e E, count, coalesce bool) error {
+	if count {
+		t.publishCounter.Inc()
+	}
+
+	var subscribers []*eventSubscriber[E]
+	if t.retainEnabled.Load() {
+		t.mutex.Lock()
+		t.retain(e)
+		subscribers = t.loadSubscribers()
+		t.mutex.Unlock()
+	} else {
+		subscribers = t.loadSubscribers()
+	}
+
+	var errs []error
+	for _, sub := range subscribers {
+		if !sub.accept(e) {
+			continue
+		}
+		if sub.replaying.Load() && sub.holdDuringReplay(ctx, e, coalesce) {
+			continue
+		}
+		var err error
+		if coalesce {
+			err = sub.deliverLatest(ctx, e)
+		} else {
+			err = sub.deliver(ctx, e)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Subscribe subscribes the handler to the events whose payload type is E. The subKey identifies the
+// subscriber, such as the id of a component, and the subscriptions of a component made under its id are cancelled
+// when the component is removed, replaced or shut down, but kept when it is stopped and restarted. The handler is called on the publisher goroutine unless another delivery mode is given,
+// a handler that may be slow should use WithAsyncDelivery or WithPoolDelivery so that it does not stall the publisher.
+func Subscribe[E any](subKey EventSubKey, handler func(ctx context.Context, e E), opts ...SubscribeOption) (*Subscription, error) {
+	return getTypedEventTopic[E]().subscribe(subKey, handler, opts...)
+}
+
+// Publish delivers the event to the handlers subscribed to the payload type E.
+func Publish[E any](e E) error {
+	return PublishContext(context.Background(), e)
+}
+
+func PublishContext[E any](ctx context.Context, e E) error {
+	return getTypedEventTopic[E]().publish(ctx, e, true, false)
+}
+
+// publishLatest publishes an event of the frame, a full queue of a subscription keeps the latest event instead of
+// blocking the publisher or dropping the event.
+func publishLatest[E any](e E) error {
+	return getTypedEventTopic[E]().publish(context.Background(), e, true, true)
+}
+
+// UnsubscribeAll cancels all subscriptions made under the subKey and returns the number of cancelled subscriptions.
+func UnsubscribeAll(subKey EventSubKey) int {
+	var num int
+	for _, sub := range eventBusInst.getSubscriptions(subKey) {
+		if sub.Cancel() {
+			num++
+		}
+	}
+	return num
+}
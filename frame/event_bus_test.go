@@ -0,0 +1,130 @@
+package frame
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testBusEvent struct {
+	Seq int
+}
+
+type testBusOtherEvent struct {
+	Seq int
+}
+
+func TestTypedEventBus(t *testing.T) {
+	t.Cleanup(func() {
+		UnsubscribeAll("first")
+		UnsubscribeAll("second")
+	})
+
+	var got []string
+	if _, err := Subscribe("first", func(ctx context.Context, e testBusEvent) {
+		got = append(got, "first")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Subscribe("second", func(ctx context.Context, e testBusEvent) {
+		got = append(got, "second")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Subscribe("first", func(ctx context.Context, e testBusOtherEvent) {
+		got = append(got, "other")
+	}); err != nil {
+		t.Fatalf("the same key cannot subscribe to another payload type, %v", err)
+	}
+
+	if _, err := Subscribe[testBusEvent]("nil", nil); err == nil {
+		t.Error("a nil handler has been subscribed")
+	}
+
+	// Handlers run synchronously in the order of subscription, and only for their payload type
+	if err := Publish(testBusEvent{Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "first,second" {
+		t.Fatalf("got handlers %v", got)
+	}
+}
+
+type testBusPanicEvent struct{}
+
+func TestTypedEventBusRecoversHandlerPanics(t *testing.T) {
+	called := false
+	t.Cleanup(func() {
+		UnsubscribeAll("panic")
+		UnsubscribeAll("after")
+	})
+	_, _ = Subscribe("panic", func(ctx context.Context, e testBusPanicEvent) {
+		panic("broken handler")
+	})
+	_, _ = Subscribe("after", func(ctx context.Context, e testBusPanicEvent) {
+		called = true
+	})
+
+	err := Publish(testBusPanicEvent{})
+	if err == nil || !strings.Contains(err.Error(), "broken handler") {
+		t.Fatalf("got error %v, want the panic", err)
+	}
+	if !called {
+		t.Error("the handler after the panic has not been called")
+	}
+}
+
+func TestEventMessageAdapter(t *testing.T) {
+	const event EventType = 1000
+	var got []interface{}
+	t.Cleanup(func() {
+		UnsubscribeAll("adapter")
+	})
+	if err := SubscribeEventMessage(event, "adapter", func(args ...interface{}) {
+		got = args
+	}, "pre"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SubscribeEventMessage(event, "adapter", nil); err == nil {
+		t.Error("a nil handle has been subscribed")
+	}
+
+	if err := PublishEventMessage(event, 1, "two"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0] != "pre" || got[1] != 1 || got[2] != "two" {
+		t.Fatalf("got args %v, want the pre args followed by the published args", got)
+	}
+}
+
+type testBusAllocEvent struct {
+	Seq int
+}
+
+func TestPublishDoesNotAllocate(t *testing.T) {
+	t.Cleanup(func() {
+		UnsubscribeAll("alloc")
+	})
+
+	var sum int
+	if _, err := Subscribe("alloc", func(ctx context.Context, e testBusAllocEvent) {
+		sum += e.Seq
+	}, WithPriority(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Subscribe("alloc", func(ctx context.Context, e testBusAllocEvent) {
+		sum -= e.Seq
+	}, WithFilter(func(e testBusAllocEvent) bool { return e.Seq > 0 })); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Subscribe("alloc", func(ctx context.Context, e testBusAllocEvent) {},
+		WithAsyncDelivery(8, OverflowDropNewest)); err != nil {
+		t.Fatal(err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		_ = Publish(testBusAllocEvent{Seq: 1})
+	}); allocs != 0 {
+		t.Errorf("publishing allocates %v times, want 0", allocs)
+	}
+}
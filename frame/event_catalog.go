@@ -0,0 +1,159 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// The lifecycle events published by the frame. The payload type of each event is documented on it, a handler
+// can subscribe to the payload type with Subscribe, or to the event type with SubscribeEventMessage and receive
+// the pre args of the subscription followed by exactly one payload argument.
+const (
+	// EventAPPStarted is published after all components and sub processes have started, the payload is APPStartedEvent
+	EventAPPStarted EventType = iota + 1
+	// EventAPPStopping is published when shutdown begins, before the drain period and before components stop,
+	// the payload is APPStoppingEvent
+	EventAPPStopping
+	// EventAPPStopped is published after all components and watchers have stopped, the payload is APPStoppedEvent
+	EventAPPStopped
+	// EventSignalReceived is published when the application receives a termination signal,
+	// the payload is SignalReceivedEvent
+	EventSignalReceived
+	// EventComponentsInitialized is published after all configured components have been initialized,
+	// the payload is ComponentsInitializedEvent
+	EventComponentsInitialized
+	// EventComponentsStarted is published after all configured components have started,
+	// the payload is ComponentsStartedEvent
+	EventComponentsStarted
+	// EventComponentStatusChanged is published whenever a component changes its status,
+	// the payload is ComponentStatusChangedEvent
+	EventComponentStatusChanged
+	// EventConfigLoaded is published when a configuration is loaded for the first time, the payload is ConfigEvent
+	EventConfigLoaded
+	// EventConfigUpdated is published when the content of a loaded configuration changes, the payload is ConfigEvent
+	EventConfigUpdated
+	// EventConfigRemoved is published when the file of a configuration is removed, the payload is ConfigEvent
+	EventConfigRemoved
+	// EventConfigFailed is published when a configuration fails to load for a reason other than a rejection of its
+	// content, such as an unreadable file, the payload is ConfigEvent
+	EventConfigFailed
+	// EventMemoryLimitChanged is published when the memory usage limit changes, the payload is MemoryLimitChangedEvent
+	EventMemoryLimitChanged
+	// EventGCForced is published after a GC is forced by the frame, the payload is GCForcedEvent
+	EventGCForced
+	// EventSubProcessStarted is published when a sub process starts, the payload is SubProcessStartedEvent
+	EventSubProcessStarted
+	// EventSubProcessExited is published when a sub process exits, the payload is SubProcessExitedEvent
+	EventSubProcessExited
+	// EventConfigRejected is published when the content of a configuration fails to decode, validate or commit,
+	// and the last good configuration is kept, the payload is ConfigEvent whose Err is a *ConfigRejectedError
+	EventConfigRejected
+)
+
+var eventTypeNames = map[EventType]string{
+	EventAPPStarted:             "APPStarted",
+	EventAPPStopping:            "APPStopping",
+	EventAPPStopped:             "APPStopped",
+	EventSignalReceived:         "SignalReceived",
+	EventComponentsInitialized:  "ComponentsInitialized",
+	EventComponentsStarted:      "ComponentsStarted",
+	EventComponentStatusChanged: "ComponentStatusChanged",
+	EventConfigLoaded:           "ConfigLoaded",
+	EventConfigUpdated:          "ConfigUpdated",
+	EventConfigRemoved:          "ConfigRemoved",
+	EventConfigFailed:           "ConfigFailed",
+	EventMemoryLimitChanged:     "MemoryLimitChanged",
+	EventGCForced:               "GCForced",
+	EventSubProcessStarted:      "SubProcessStarted",
+	EventSubProcessExited:       "SubProcessExited",
+	EventConfigRejected:         "ConfigRejected",
+}
+
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventType(%d)", uint16(t))
+}
+
+type APPStartedEvent struct {
+	AppID           string
+	StartupDuration time.Duration
+}
+
+type APPStoppingEvent struct {
+	AppID       string
+	Signal      os.Signal
+	DrainPeriod time.Duration
+}
+
+type APPStoppedEvent struct {
+	AppID            string
+	ShutdownDuration time.Duration
+}
+
+type SignalReceivedEvent struct {
+	Signal os.Signal
+}
+
+type ComponentsInitializedEvent struct {
+	ComponentIDList []ComponentID
+}
+
+type ComponentsStartedEvent struct {
+	ComponentIDList []ComponentID
+}
+
+// ComponentStatusChangedEvent describes a status transition of a component, Err is the cause of a failed status.
+type ComponentStatusChangedEvent struct {
+	ID   ComponentID
+	Type ComponentType
+	From ComponentStatus
+	To   ComponentStatus
+	Err  error
+}
+
+// ConfigEvent describes a change of a configuration, Type is the event type such as EventConfigUpdated,
+// Version is the version after the change and Err is the cause of a failed load.
+type ConfigEvent struct {
+	Type    EventType
+	Key     string
+	Path    string
+	Version int
+	Err     error
+}
+
+type MemoryLimitChangedEvent struct {
+	BeforeLimitBytes int64
+	LimitBytes       int64
+}
+
+// GCForcedEvent describes a forced GC, Reason is either "policy" or "admin".
+type GCForcedEvent struct {
+	Reason   string
+	Duration time.Duration
+}
+
+type SubProcessStartedEvent struct {
+	Pid  int
+	Args string
+}
+
+type SubProcessExitedEvent struct {
+	Pid int
+	Err error
+}
+
+// publishFrameEvent publishes a lifecycle event of the frame to the handlers subscribed to the payload type
+// with Subscribe and to the handlers subscribed to the event type with SubscribeEventMessage. A full queue of a
+// subscription does not block the caller, which may be the startup or a configuration reload, see publishLatest.
+func publishFrameEvent[E any](event EventType, payload E) {
+	if err := publishLatest(payload); err != nil {
+		getLoggerInst().WarningF("Failed to publish event %v, %v", event, err)
+	}
+	if err := getEventMessageTopic(event).publish(context.Background(), eventMessage{args: []interface{}{payload}}, false, true); err != nil {
+		getLoggerInst().WarningF("Failed to publish event message %v, %v", event, err)
+	}
+}
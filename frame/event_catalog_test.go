@@ -0,0 +1,116 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// subscribeTestEvents records the payloads of the events published while the test runs. The events of the frame
+// are delivered on the goroutine of the subscription, so the returned function waits for count payloads.
+func subscribeTestEvents(t *testing.T, events ...EventType) func(count int) []interface{} {
+	t.Helper()
+
+	var mutex sync.Mutex
+	var payloads []interface{}
+	subKey := t.Name()
+	for _, event := range events {
+		if err := SubscribeEventMessage(event, subKey, func(args ...interface{}) {
+			mutex.Lock()
+			payloads = append(payloads, args[0])
+			mutex.Unlock()
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		UnsubscribeAll(subKey)
+	})
+	// Discard the retained events replayed to the new subscriptions
+	mutex.Lock()
+	payloads = nil
+	mutex.Unlock()
+
+	return func(count int) []interface{} {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mutex.Lock()
+			if len(payloads) >= count || time.Now().After(deadline) {
+				ret := payloads
+				payloads = nil
+				mutex.Unlock()
+				return ret
+			}
+			mutex.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestComponentLifecycleEvents(t *testing.T) {
+	takePayloads := subscribeTestEvents(t, EventComponentsInitialized, EventComponentsStarted, EventComponentStatusChanged)
+
+	mgr := &ComponentMgr{}
+	if err := mgr.initialize([]ComponentConfigModel{{ID: "store", ComponentType: string(testMgrStoreType)}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.startAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = mgr.stopAll(context.Background())
+		takeTestMgrEvents()
+	})
+
+	want := []interface{}{
+		ComponentStatusChangedEvent{ID: "store", Type: testMgrStoreType, From: ComponentCreatedStatus, To: ComponentCreatedStatus},
+		ComponentStatusChangedEvent{ID: "store", Type: testMgrStoreType, From: ComponentCreatedStatus, To: ComponentInitializedStatus},
+		ComponentsInitializedEvent{ComponentIDList: []ComponentID{"store"}},
+		ComponentStatusChangedEvent{ID: "store", Type: testMgrStoreType, From: ComponentInitializedStatus, To: ComponentStartingStatus},
+		ComponentStatusChangedEvent{ID: "store", Type: testMgrStoreType, From: ComponentStartingStatus, To: ComponentRunningStatus},
+		ComponentsStartedEvent{ComponentIDList: []ComponentID{"store"}},
+	}
+	// Each event type is delivered in order, but the order across event types is up to the subscriptions
+	got := takePayloads(len(want))
+	if len(got) != len(want) {
+		t.Fatalf("got events %+v, want %+v", got, want)
+	}
+	gotByType, wantByType := make(map[string][]string), make(map[string][]string)
+	for idx := range want {
+		gotByType[fmt.Sprintf("%T", got[idx])] = append(gotByType[fmt.Sprintf("%T", got[idx])], fmt.Sprintf("%+v", got[idx]))
+		wantByType[fmt.Sprintf("%T", want[idx])] = append(wantByType[fmt.Sprintf("%T", want[idx])], fmt.Sprintf("%+v", want[idx]))
+	}
+	for tpy, wantEvents := range wantByType {
+		if strings.Join(gotByType[tpy], "; ") != strings.Join(wantEvents, "; ") {
+			t.Errorf("got %v events %v, want %v", tpy, gotByType[tpy], wantEvents)
+		}
+	}
+
+	// A failed status carries its cause
+	component, _ := mgr.GetComponentByID("store")
+	cause := errors.New("broken")
+	_ = component.getBaseComponent().transitStatus(ComponentStoppingStatus, nil)
+	_ = component.getBaseComponent().transitStatus(ComponentFailedStatus, cause)
+	got = takePayloads(2)
+	if len(got) != 2 {
+		t.Fatalf("got events %+v, want the stopping and the failed status", got)
+	}
+	if failed := got[len(got)-1].(ComponentStatusChangedEvent); failed.To != ComponentFailedStatus || failed.Err != cause {
+		t.Errorf("got %+v, want the failure with its cause", failed)
+	}
+}
+
+func TestEventTypeString(t *testing.T) {
+	for event, name := range eventTypeNames {
+		if got := event.String(); got != name {
+			t.Errorf("got %q for %d, want %q", got, event, name)
+		}
+	}
+	if got := EventType(0).String(); got != "EventType(0)" {
+		t.Errorf("got %q for an undefined event", got)
+	}
+}
@@ -0,0 +1,398 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultEventQueueSize      = 256
+	defaultEventPoolWorkersNum = 8
+	defaultEventPoolQueueSize  = 4096
+)
+
+// DeliveryMode determines the goroutine on which the handler of a subscription is called.
+type DeliveryMode uint8
+
+const (
+	// DeliverySync calls the handler on the publisher goroutine
+	DeliverySync DeliveryMode = iota
+	// DeliveryAsync calls the handler on a goroutine of the subscription, fed by a bounded queue
+	DeliveryAsync
+	// DeliveryPool calls the handler on the shared worker pool of the event bus
+	DeliveryPool
+)
+
+func (t DeliveryMode) String() string {
+	switch t {
+	case DeliverySync:
+		return "sync"
+	case DeliveryAsync:
+		return "async"
+	case DeliveryPool:
+		return "pool"
+	}
+	return "unknown"
+}
+
+// OverflowPolicy determines what happens when an event is delivered to a full queue.
+type OverflowPolicy uint8
+
+const (
+	// OverflowBlock blocks the publisher until the queue has room
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued event to make room for the new one, it is not supported by DeliveryPool
+	// because the queue of the worker pool is shared by the subscriptions
+	OverflowDropOldest
+	// OverflowDropNewest discards the new event
+	OverflowDropNewest
+)
+
+func (t OverflowPolicy) String() string {
+	switch t {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowDropNewest:
+		return "drop_newest"
+	}
+	return "unknown"
+}
+
+type subscribeOptions struct {
+	mode      DeliveryMode
+	queueSize int
+	overflow  OverflowPolicy
+	priority  int
+	once      bool
+	noReplay  bool
+	filters   []interface{}
+}
+
+// SubscribeOption configures a subscription made with Subscribe.
+type SubscribeOption func(opts *subscribeOptions)
+
+// WithSyncDelivery calls the handler on the publisher goroutine, which is the default.
+func WithSyncDelivery() SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.mode = DeliverySync
+	}
+}
+
+// WithAsyncDelivery calls the handler on a dedicated goroutine of the subscription in the order of publishing.
+// The events wait in a queue of queueSize, and the policy applies when the queue is full.
+func WithAsyncDelivery(queueSize int, policy OverflowPolicy) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.mode = DeliveryAsync
+		opts.queueSize = queueSize
+		opts.overflow = policy
+	}
+}
+
+// WithPoolDelivery calls the handler on the shared worker pool of the event bus, events may be handled
+// concurrently and out of order. The policy applies when the queue of the worker pool is full, and must be
+// OverflowBlock or OverflowDropNewest.
+func WithPoolDelivery(policy OverflowPolicy) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.mode = DeliveryPool
+		opts.overflow = policy
+	}
+}
+
+func newSubscribeOptions(opts []SubscribeOption) subscribeOptions {
+	retOpts := subscribeOptions{mode: DeliverySync, queueSize: defaultEventQueueSize, overflow: OverflowBlock}
+	for _, opt := range opts {
+		opt(&retOpts)
+	}
+	if retOpts.queueSize <= 0 {
+		retOpts.queueSize = defaultEventQueueSize
+	}
+	return retOpts
+}
+
+type eventEnvelope[E any] struct {
+	ctx context.Context
+	e   E
+}
+
+// heldEvent is an event published during the replay of a subscriber, it is delivered once the replay is done.
+type heldEvent[E any] struct {
+	env      eventEnvelope[E]
+	coalesce bool
+}
+
+type eventSubscriber[E any] struct {
+	key          EventSubKey
+	handle       func(ctx context.Context, e E)
+	subscription *Subscription
+	mode         DeliveryMode
+	overflow     OverflowPolicy
+	priority     int
+	filters      []func(E) bool
+	once         bool
+	fired        atomic.Bool
+	queue        chan eventEnvelope[E]
+	done         chan struct{}
+	stopOnce     sync.Once
+	// latest keeps the newest event of the frame that did not fit in a full queue, see deliverLatest
+	latestMutex  sync.Mutex
+	latest       *eventEnvelope[E]
+	latestSignal chan struct{}
+	// replaying holds back the events published while the retained events are replayed, see eventTopic.replay
+	replaying   atomic.Bool
+	replayMutex sync.Mutex
+	replayHeld  []heldEvent[E]
+
+	topicName         string
+	durationHistogram *Histogram
+	deliveredCounter  *Counter
+	droppedCounter    *Counter
+	coalescedCounter  *Counter
+	panicCounter      *Counter
+}
+
+func newEventSubscriber[E any](topic *eventTopic[E], key EventSubKey, handle func(ctx context.Context, e E),
+	opts subscribeOptions) *eventSubscriber[E] {
+
+	sub := &eventSubscriber[E]{
+		key:               key,
+		handle:            handle,
+		mode:              opts.mode,
+		overflow:          opts.overflow,
+		priority:          opts.priority,
+		once:              opts.once,
+		done:              make(chan struct{}),
+		topicName:         topic.name,
+		durationHistogram: topic.durationHistogram,
+		deliveredCounter:  eventDeliveredCounter.WithLabelValues(topic.name, opts.mode.String()),
+		droppedCounter:    eventDroppedCounter.WithLabelValues(topic.name, opts.overflow.String()),
+		coalescedCounter:  eventDroppedCounter.WithLabelValues(topic.name, "coalesced"),
+		panicCounter:      eventHandlerPanicCounter.WithLabelValues(topic.name),
+	}
+
+	if sub.mode == DeliveryAsync {
+		sub.queue = make(chan eventEnvelope[E], opts.queueSize)
+		sub.latestSignal = make(chan struct{}, 1)
+		go sub.loopDeliver()
+	}
+	return sub
+}
+
+// accept reports whether the event passes the filters of the subscription, a one-shot subscription
+// accepts only the first event.
+func (t *eventSubscriber[E]) accept(e E) bool {
+	for _, filter := range t.filters {
+		if !filter(e) {
+			return false
+		}
+	}
+	if t.once {
+		return t.fired.CompareAndSwap(false, true)
+	}
+	return true
+}
+
+// deliver hands the event to the handler according to the delivery mode of the subscription.
+// Only a synchronous handler can return an error, which is the recovered panic of the handler.
+func (t *eventSubscriber[E]) deliver(ctx context.Context, e E) error {
+	switch t.mode {
+	case DeliveryAsync:
+		enqueueWithPolicy(t.queue, eventEnvelope[E]{ctx: ctx, e: e}, t.overflow, t.done, t.droppedCounter)
+		return nil
+	case DeliveryPool:
+		getEventWorkerPool().submit(func() {
+			_ = t.call(ctx, e)
+		}, t.overflow, t.done, t.droppedCounter)
+		return nil
+	}
+	return t.call(ctx, e)
+}
+
+// deliverLatest hands an event published by the frame to the handler without dropping it or blocking the publisher
+// on a full queue. A synchronous handler is called on the publisher goroutine as usual. When the queue of an
+// asynchronous subscription or of the worker pool is full, the event waits in a slot of the subscription instead,
+// and a later event replaces it there, so that a burst is coalesced to the latest event, which is always delivered.
+// An asynchronous subscription still handles the events in the order of publishing.
+func (t *eventSubscriber[E]) deliverLatest(ctx context.Context, e E) error {
+	env := eventEnvelope[E]{ctx: ctx, e: e}
+	switch t.mode {
+	case DeliveryAsync:
+		t.latestMutex.Lock()
+		defer t.latestMutex.Unlock()
+		// Once an event waits in the slot, the later events follow it there to keep the order
+		if t.latest == nil {
+			select {
+			case t.queue <- env:
+				return nil
+			default:
+			}
+		}
+		t.setLatestLocked(env)
+		select {
+		case t.latestSignal <- struct{}{}:
+		default:
+		}
+		return nil
+	case DeliveryPool:
+		pool := getEventWorkerPool()
+		t.latestMutex.Lock()
+		defer t.latestMutex.Unlock()
+		if t.latest == nil {
+			select {
+			case pool.tasks <- func() { _ = t.call(ctx, e) }:
+				return nil
+			default:
+			}
+		}
+		if t.setLatestLocked(env) {
+			return nil
+		}
+		// The task takes the latest event when it runs, and waits for room in the pool without holding up the publisher
+		go pool.submit(func() {
+			if latest := t.takeLatest(); latest != nil {
+				_ = t.call(latest.ctx, latest.e)
+			}
+		}, OverflowBlock, t.done, nil)
+		return nil
+	}
+	return t.call(ctx, e)
+}
+
+// setLatestLocked puts the event in the slot and reports whether it replaced a waiting event,
+// the caller must hold the latestMutex.
+func (t *eventSubscriber[E]) setLatestLocked(env eventEnvelope[E]) bool {
+	replaced := t.latest != nil
+	if replaced {
+		t.coalescedCounter.Inc()
+	}
+	t.latest = &env
+	return replaced
+}
+
+func (t *eventSubscriber[E]) takeLatest() *eventEnvelope[E] {
+	t.latestMutex.Lock()
+	defer t.latestMutex.Unlock()
+	latest := t.latest
+	t.latest = nil
+	return latest
+}
+
+func (t *eventSubscriber[E]) loopDeliver() {
+	for {
+		select {
+		case <-t.done:
+			return
+		case env := <-t.queue:
+			_ = t.call(env.ctx, env.e)
+		case <-t.latestSignal:
+			// The event in the slot is newer than the queued events, so it is handled after them
+			for drained := false; !drained; {
+				select {
+				case env := <-t.queue:
+					_ = t.call(env.ctx, env.e)
+				default:
+					drained = true
+				}
+			}
+			if latest := t.takeLatest(); latest != nil {
+				_ = t.call(latest.ctx, latest.e)
+			}
+		}
+	}
+}
+
+// call calls the handler and recovers a panic of the handler, so that the other handlers still receive the event.
+func (t *eventSubscriber[E]) call(ctx context.Context, e E) (retErr error) {
+	begin := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			t.panicCounter.Inc()
+			retErr = fmt.Errorf("handler %v of event %v panicked, %v", t.key, t.topicName, r)
+			getLoggerInst().ErrorF("The handler %v of event %v panicked, %v, Stack: %s", t.key, t.topicName, r, debug.Stack())
+			return
+		}
+		t.deliveredCounter.Inc()
+		t.durationHistogram.Observe(time.Since(begin).Seconds())
+	}()
+	if t.once {
+		defer t.subscription.Cancel()
+	}
+
+	t.handle(ctx, e)
+	return nil
+}
+
+// stop stops the goroutines of the subscription, the queued events are discarded.
+func (t *eventSubscriber[E]) stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// enqueueWithPolicy sends the item to the queue, applying the overflow policy when the queue is full.
+func enqueueWithPolicy[T any](queue chan T, item T, policy OverflowPolicy, done <-chan struct{}, droppedCounter *Counter) {
+	select {
+	case queue <- item:
+		return
+	default:
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		droppedCounter.Inc()
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- item:
+				return
+			default:
+			}
+			select {
+			case <-queue:
+				droppedCounter.Inc()
+			default:
+			}
+		}
+	default:
+		select {
+		case queue <- item:
+		case <-done:
+		}
+	}
+}
+
+var (
+	eventWorkerPoolInst *eventWorkerPool
+	eventWorkerPoolOnce sync.Once
+)
+
+// eventWorkerPool is shared by the subscriptions with DeliveryPool, it is started on first use.
+type eventWorkerPool struct {
+	tasks chan func()
+}
+
+func getEventWorkerPool() *eventWorkerPool {
+	eventWorkerPoolOnce.Do(func() {
+		eventWorkerPoolInst = &eventWorkerPool{tasks: make(chan func(), defaultEventPoolQueueSize)}
+		for i := 0; i < defaultEventPoolWorkersNum; i++ {
+			go eventWorkerPoolInst.loopWork()
+		}
+	})
+	return eventWorkerPoolInst
+}
+
+// submit queues the task, a publisher blocked by a full queue is released when the subscription is cancelled.
+func (t *eventWorkerPool) submit(task func(), policy OverflowPolicy, done <-chan struct{}, droppedCounter *Counter) {
+	enqueueWithPolicy(t.tasks, task, policy, done, droppedCounter)
+}
+
+func (t *eventWorkerPool) loopWork() {
+	for task := range t.tasks {
+		task()
+	}
+}
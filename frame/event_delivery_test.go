@@ -0,0 +1,211 @@
+package frame
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testDeliveryEvent struct {
+	Seq int
+}
+
+type testLatestConfig struct {
+	Version int
+}
+
+type testPoolEvent struct{}
+
+func TestAsyncDeliveryKeepsThePublishingOrder(t *testing.T) {
+	const total = 100
+	received := make(chan int, total)
+	subscription, err := Subscribe("test_async_order", func(_ context.Context, e testDeliveryEvent) {
+		received <- e.Seq
+	}, WithAsyncDelivery(total, OverflowBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscription.Cancel()
+
+	for seq := 0; seq < total; seq++ {
+		if err := Publish(testDeliveryEvent{Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for want := 0; want < total; want++ {
+		select {
+		case seq := <-received:
+			if seq != want {
+				t.Fatalf("received event %d, want %d", seq, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", want)
+		}
+	}
+}
+
+func TestEnqueueWithPolicy(t *testing.T) {
+	dropped := eventDroppedCounter.WithLabelValues("test_enqueue", "test")
+
+	queue := make(chan int, 2)
+	queue <- 1
+	queue <- 2
+	enqueueWithPolicy(queue, 3, OverflowDropNewest, nil, dropped)
+	if got := []int{<-queue, <-queue}; got[0] != 1 || got[1] != 2 {
+		t.Errorf("OverflowDropNewest left %v in the queue, want [1 2]", got)
+	}
+
+	queue <- 1
+	queue <- 2
+	enqueueWithPolicy(queue, 3, OverflowDropOldest, nil, dropped)
+	if got := []int{<-queue, <-queue}; got[0] != 2 || got[1] != 3 {
+		t.Errorf("OverflowDropOldest left %v in the queue, want [2 3]", got)
+	}
+
+	// OverflowBlock waits until the queue has room or the subscription is stopped
+	queue <- 1
+	queue <- 2
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		enqueueWithPolicy(queue, 3, OverflowBlock, done, dropped)
+	}()
+	<-queue
+	wg.Wait()
+	if got := []int{<-queue, <-queue}; got[0] != 2 || got[1] != 3 {
+		t.Errorf("OverflowBlock left %v in the queue, want [2 3]", got)
+	}
+
+	queue <- 1
+	queue <- 2
+	close(done)
+	enqueueWithPolicy(queue, 3, OverflowBlock, done, dropped)
+}
+
+func TestPoolDeliveryRejectsDropOldest(t *testing.T) {
+	if _, err := Subscribe("test_pool", func(context.Context, testPoolEvent) {}, WithPoolDelivery(OverflowDropOldest)); err == nil {
+		t.Fatal("subscribing with the pool delivery and OverflowDropOldest succeeded, want an error")
+	}
+}
+
+func TestFrameEventsAreCoalescedToTheLatest(t *testing.T) {
+	const total = 100
+	release := make(chan struct{})
+	asyncReceived := make(chan int, total)
+	poolReceived := make(chan int, total)
+	var syncCalls int
+
+	_, asyncErr := Subscribe("test_latest", func(_ context.Context, e ConfigChange[testLatestConfig]) {
+		<-release
+		asyncReceived <- e.New.Version
+	}, WithAsyncDelivery(2, OverflowBlock))
+	_, poolErr := Subscribe("test_latest", func(_ context.Context, e ConfigChange[testLatestConfig]) {
+		poolReceived <- e.New.Version
+	}, WithPoolDelivery(OverflowDropNewest))
+	_, syncErr := Subscribe("test_latest", func(context.Context, ConfigChange[testLatestConfig]) {
+		syncCalls++
+	})
+	if asyncErr != nil || poolErr != nil || syncErr != nil {
+		t.Fatal(asyncErr, poolErr, syncErr)
+	}
+	defer UnsubscribeAll("test_latest")
+
+	// Occupy the workers and fill the queue of the pool, so that no event reaches the pool subscription either
+	pool := getEventWorkerPool()
+	started := make(chan struct{}, defaultEventPoolWorkersNum)
+	for idx := 0; idx < defaultEventPoolWorkersNum; idx++ {
+		pool.submit(func() {
+			started <- struct{}{}
+			<-release
+		}, OverflowBlock, nil, nil)
+	}
+	for idx := 0; idx < defaultEventPoolWorkersNum; idx++ {
+		<-started
+	}
+	for idx := 0; idx < defaultEventPoolQueueSize; idx++ {
+		pool.submit(func() {}, OverflowBlock, nil, nil)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for version := 1; version <= total; version++ {
+			_ = publishLatest(ConfigChange[testLatestConfig]{Key: "test_latest", New: &testLatestConfig{Version: version}})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing events of the frame blocked on full queues")
+	}
+	if syncCalls != total {
+		t.Errorf("the synchronous handler was called %d times, want %d", syncCalls, total)
+	}
+	close(release)
+
+	// The asynchronous subscription receives the events in order, and both end with the latest one
+	for name, received := range map[string]chan int{"async": asyncReceived, "pool": poolReceived} {
+		last := 0
+		for last != total {
+			select {
+			case version := <-received:
+				if name == "async" && version <= last {
+					t.Fatalf("the %v subscription received version %d after %d", name, version, last)
+				}
+				if version > last {
+					last = version
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("the %v subscription received version %d at last, want %d", name, last, total)
+			}
+		}
+	}
+}
+
+func TestPoolDeliveryBlockIsReleasedOnCancel(t *testing.T) {
+	pool := getEventWorkerPool()
+	release := make(chan struct{})
+	defer close(release)
+
+	// Occupy the workers and then fill the queue of the pool
+	started := make(chan struct{}, defaultEventPoolWorkersNum)
+	for idx := 0; idx < defaultEventPoolWorkersNum; idx++ {
+		pool.submit(func() {
+			started <- struct{}{}
+			<-release
+		}, OverflowBlock, nil, nil)
+	}
+	for idx := 0; idx < defaultEventPoolWorkersNum; idx++ {
+		<-started
+	}
+	for idx := 0; idx < defaultEventPoolQueueSize; idx++ {
+		pool.submit(func() { <-release }, OverflowBlock, nil, nil)
+	}
+
+	subscription, err := Subscribe("test_pool", func(context.Context, testPoolEvent) {}, WithPoolDelivery(OverflowBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_ = Publish(testPoolEvent{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("publishing to a full pool did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	subscription.Cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the subscription did not release the publisher")
+	}
+}
@@ -1,7 +1,9 @@
 package frame
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/akley-MK4/pubsub"
 )
@@ -9,44 +11,59 @@ import (
 type EventType uint16
 type EventSubKey interface{}
 
-const (
-	EventAPPStarted EventType = iota + 1
-)
-
 var (
-	eventMessageMgrInst    *eventMessageMgr
+	// ErrInvalidEventMsgInst is no longer returned, the event bus is available before the application launches.
+	//
+	// Deprecated: kept for compatibility.
 	ErrInvalidEventMsgInst = errors.New("invalid eventMessageMgr instance")
 )
 
-type eventMessageMgr struct {
-	obs *pubsub.ObServer
+// eventMessage is the payload of a legacy event message published with PublishEventMessage.
+type eventMessage struct {
+	args []interface{}
 }
 
-func initializeEventMessageMgr() error {
-	obs, newObsErr := pubsub.NewObServer(true)
-	if newObsErr != nil {
-		return newObsErr
-	}
-
-	eventMessageMgrInst = &eventMessageMgr{
-		obs: obs,
-	}
-
-	return nil
+func getEventMessageTopic(event EventType) *eventTopic[eventMessage] {
+	return getEventTopic[eventMessage](event, event.String())
 }
 
+// PublishEventMessage publishes the args to the handlers subscribed to the event with SubscribeEventMessage.
+// It is an adapter of the typed event bus, prefer Publish for new events.
 func PublishEventMessage(event EventType, args ...interface{}) error {
-	if eventMessageMgrInst == nil {
-		return ErrInvalidEventMsgInst
-	}
-
-	return eventMessageMgrInst.obs.Publish(event, false, args...)
+	return getEventMessageTopic(event).publish(context.Background(), eventMessage{args: args}, true, false)
 }
 
+// SubscribeEventMessage subscribes the handle to the event. The handle receives the preArgs followed by
+// the args of the published event message. It is an adapter of the typed event bus, prefer Subscribe for new events.
+// The subscriptions of a component made under its id are kept across its restarts, and cancelled when the component
+// is removed, replaced or shut down.
 func SubscribeEventMessage(event EventType, subKey EventSubKey, handle pubsub.TopicFunc, preArgs ...interface{}) error {
-	if eventMessageMgrInst == nil {
-		return ErrInvalidEventMsgInst
+	if handle == nil {
+		return fmt.Errorf("the handle subscribed to event %v is nil", event)
 	}
+	_, err := getEventMessageTopic(event).subscribe(subKey, func(_ context.Context, msg eventMessage) {
+		if len(preArgs) == 0 {
+			handle(msg.args...)
+			return
+		}
+		args := make([]interface{}, 0, len(preArgs)+len(msg.args))
+		args = append(args, preArgs...)
+		args = append(args, msg.args...)
+		handle(args...)
+	})
+	return err
+}
 
-	return eventMessageMgrInst.obs.Subscribe(event, subKey, handle, preArgs...)
+// UnsubscribeEventMessage cancels the subscriptions of the event made under the subKey with SubscribeEventMessage.
+func UnsubscribeEventMessage(event EventType, subKey EventSubKey) error {
+	var num int
+	for _, sub := range eventBusInst.getSubscriptions(subKey) {
+		if sub.topicKey == event && sub.Cancel() {
+			num++
+		}
+	}
+	if num == 0 {
+		return fmt.Errorf("event %v has no subscriber with key %v", event, subKey)
+	}
+	return nil
 }
@@ -0,0 +1,150 @@
+package frame
+
+import (
+	"context"
+)
+
+func init() {
+	// The lifecycle events of the application happen once, retain them so that late subscribers still receive them
+	RetainEvent[ComponentsInitializedEvent](1)
+	RetainEvent[ComponentsStartedEvent](1)
+	RetainEvent[APPStartedEvent](1)
+	RetainEvent[APPStoppingEvent](1)
+	RetainEvent[APPStoppedEvent](1)
+	for _, event := range []EventType{EventComponentsInitialized, EventComponentsStarted, EventAPPStarted,
+		EventAPPStopping, EventAPPStopped} {
+		RetainEventMessage(event, 1)
+	}
+}
+
+// setRetainSize keeps the last size events of the topic, a size of 0 disables the retention and discards
+// the retained events.
+func (t *eventTopic[E]) setRetainSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.retainSize = size
+	if len(t.retained) > size {
+		t.retained = append([]E(nil), t.retained[len(t.retained)-size:]...)
+	}
+	t.retainEnabled.Store(size > 0)
+}
+
+// retain appends the event to the retained events, the caller must hold the mutex of the topic.
+func (t *eventTopic[E]) retain(e E) {
+	if len(t.retained) < t.retainSize {
+		t.retained = append(t.retained, e)
+		return
+	}
+	copy(t.retained, t.retained[1:])
+	t.retained[len(t.retained)-1] = e
+}
+
+func (t *eventTopic[E]) getRetained() []E {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]E(nil), t.retained...)
+}
+
+// replay delivers the retained events to a new subscriber in the order of publishing. The events published
+// meanwhile are held by holdDuringReplay and delivered after the retained ones, so that the subscriber receives
+// the events in order without the handlers being called under the mutex of the topic.
+func (t *eventTopic[E]) replay(sub *eventSubscriber[E], events []E) {
+	for _, e := range events {
+		if sub.subscription.IsCancelled() {
+			break
+		}
+		if !sub.accept(e) {
+			continue
+		}
+		if err := sub.deliver(context.Background(), e); err != nil {
+			getLoggerInst().WarningF("Failed to replay event %v to the subscriber %v, %v", t.name, sub.key, err)
+		}
+	}
+
+	for {
+		sub.replayMutex.Lock()
+		held := sub.replayHeld
+		sub.replayHeld = nil
+		if len(held) == 0 {
+			sub.replaying.Store(false)
+			sub.replayMutex.Unlock()
+			return
+		}
+		sub.replayMutex.Unlock()
+
+		for _, h := range held {
+			if sub.subscription.IsCancelled() {
+				break
+			}
+			var err error
+			if h.coalesce {
+				err = sub.deliverLatest(h.env.ctx, h.env.e)
+			} else {
+				err = sub.deliver(h.env.ctx, h.env.e)
+			}
+			if err != nil {
+				getLoggerInst().WarningF("Failed to deliver event %v to the subscriber %v after the replay, %v",
+					t.name, sub.key, err)
+			}
+		}
+	}
+}
+
+// holdDuringReplay holds the event for the subscriber whose replay is running, it returns false once the replay
+// has finished and the event is to be delivered as usual.
+func (t *eventSubscriber[E]) holdDuringReplay(ctx context.Context, e E, coalesce bool) bool {
+	t.replayMutex.Lock()
+	defer t.replayMutex.Unlock()
+	if !t.replaying.Load() {
+		return false
+	}
+	t.replayHeld = append(t.replayHeld, heldEvent[E]{env: eventEnvelope[E]{ctx: ctx, e: e}, coalesce: coalesce})
+	return true
+}
+
+// RetainEvent makes the events whose payload type is E sticky. The last historySize events are kept and
+// delivered to every new subscriber right after it subscribes, unless it subscribes with WithoutReplay.
+// A historySize of 0 disables the retention.
+func RetainEvent[E any](historySize int) {
+	getTypedEventTopic[E]().setRetainSize(historySize)
+}
+
+// RetainEventMessage makes the event message sticky, see RetainEvent.
+func RetainEventMessage(event EventType, historySize int) {
+	getEventMessageTopic(event).setRetainSize(historySize)
+}
+
+// GetRetainedEvents returns the retained events whose payload type is E in the order of publishing.
+func GetRetainedEvents[E any]() []E {
+	return getTypedEventTopic[E]().getRetained()
+}
+
+// GetLastEvent returns the last retained event whose payload type is E, and false if no event has been retained,
+// it tells whether a sticky event such as APPStartedEvent has already happened.
+func GetLastEvent[E any]() (retEvent E, retOk bool) {
+	events := GetRetainedEvents[E]()
+	if len(events) == 0 {
+		return
+	}
+	return events[len(events)-1], true
+}
+
+// GetLastEventMessage returns the args of the last retained event message, and false if none has been retained.
+func GetLastEventMessage(event EventType) ([]interface{}, bool) {
+	msgList := getEventMessageTopic(event).getRetained()
+	if len(msgList) == 0 {
+		return nil, false
+	}
+	return msgList[len(msgList)-1].args, true
+}
+
+// WithoutReplay does not deliver the retained events to the new subscription.
+func WithoutReplay() SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.noReplay = true
+	}
+}
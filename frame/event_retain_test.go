@@ -0,0 +1,173 @@
+package frame
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type testRetainEvent struct {
+	Seq int
+}
+
+func collectTestRetainEvents(t *testing.T, key string, opts ...SubscribeOption) *[]int {
+	t.Helper()
+	got := new([]int)
+	subscription, err := Subscribe(key, func(_ context.Context, e testRetainEvent) {
+		*got = append(*got, e.Seq)
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		subscription.Cancel()
+	})
+	return got
+}
+
+func TestRetainedEventsAreReplayedToLateSubscribers(t *testing.T) {
+	RetainEvent[testRetainEvent](2)
+	t.Cleanup(func() {
+		RetainEvent[testRetainEvent](0)
+	})
+
+	if _, ok := GetLastEvent[testRetainEvent](); ok {
+		t.Fatal("an event has been retained before publishing")
+	}
+	for seq := 1; seq <= 3; seq++ {
+		_ = Publish(testRetainEvent{Seq: seq})
+	}
+	if last, ok := GetLastEvent[testRetainEvent](); !ok || last.Seq != 3 {
+		t.Fatalf("got the last event %+v, %v", last, ok)
+	}
+
+	// Only the last 2 events are kept, and they come before the live events
+	late := collectTestRetainEvents(t, "test_retain_late")
+	noReplay := collectTestRetainEvents(t, "test_retain_no_replay", WithoutReplay())
+	_ = Publish(testRetainEvent{Seq: 4})
+	if got := *late; len(got) != 3 || got[0] != 2 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("the late subscriber got %v, want [2 3 4]", got)
+	}
+	if got := *noReplay; len(got) != 1 || got[0] != 4 {
+		t.Errorf("the subscriber without replay got %v, want [4]", got)
+	}
+
+	// Shrinking the history keeps the newest events, and a size of 0 discards them
+	RetainEvent[testRetainEvent](1)
+	if got := GetRetainedEvents[testRetainEvent](); len(got) != 1 || got[0].Seq != 4 {
+		t.Errorf("got retained events %+v after shrinking, want [{Seq:4}]", got)
+	}
+	RetainEvent[testRetainEvent](0)
+	if got := GetRetainedEvents[testRetainEvent](); len(got) != 0 {
+		t.Errorf("got retained events %+v after disabling the retention", got)
+	}
+}
+
+func TestRetainedEventMessage(t *testing.T) {
+	const event EventType = 1010
+	RetainEventMessage(event, 1)
+	t.Cleanup(func() {
+		RetainEventMessage(event, 0)
+		UnsubscribeAll("test_retain_message")
+	})
+
+	if err := PublishEventMessage(event, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if args, ok := GetLastEventMessage(event); !ok || len(args) != 1 || args[0] != "first" {
+		t.Fatalf("got the last event message %v, %v", args, ok)
+	}
+
+	var got []interface{}
+	if err := SubscribeEventMessage(event, "test_retain_message", func(args ...interface{}) {
+		got = args
+	}, "pre"); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "pre" || got[1] != "first" {
+		t.Errorf("got the replayed args %v, want [pre first]", got)
+	}
+}
+
+func TestOnceSubscriptionReceivesRetainedEventWhenLate(t *testing.T) {
+	if err := Publish(APPStartedEvent{AppID: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Publish(APPStartedEvent{AppID: "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls atomic.Int32
+	var received atomic.Value
+	subscription, err := Subscribe("test_once_late", func(_ context.Context, e APPStartedEvent) {
+		calls.Add(1)
+		received.Store(e.AppID)
+	}, WithOnce())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer subscription.Cancel()
+
+	if err := Publish(APPStartedEvent{AppID: "third"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("the handler was called %d times, want once", got)
+	}
+	if got := received.Load(); got != "second" {
+		t.Errorf("the handler received %v, want the last retained event second", got)
+	}
+}
+
+type testReplayOrderEvent struct {
+	Seq int
+}
+
+func TestEventsPublishedDuringReplayFollowTheRetainedEvents(t *testing.T) {
+	RetainEvent[testReplayOrderEvent](2)
+	t.Cleanup(func() {
+		RetainEvent[testReplayOrderEvent](0)
+		UnsubscribeAll("test_replay_order")
+	})
+	_ = Publish(testReplayOrderEvent{Seq: 1})
+	_ = Publish(testReplayOrderEvent{Seq: 2})
+
+	replaying := make(chan struct{})
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	var got []int
+	subscribed := make(chan error, 1)
+	go func() {
+		_, err := Subscribe("test_replay_order", func(_ context.Context, e testReplayOrderEvent) {
+			if e.Seq == 1 {
+				close(replaying)
+				<-release
+			}
+			mutex.Lock()
+			got = append(got, e.Seq)
+			mutex.Unlock()
+			// A handler publishing to its own topic during the replay must not deadlock
+			if e.Seq == 2 {
+				_ = Publish(testReplayOrderEvent{Seq: 4})
+			}
+		})
+		subscribed <- err
+	}()
+
+	// The live event is published while the replay is blocked in the handler
+	<-replaying
+	if err := Publish(testReplayOrderEvent{Seq: 3}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := <-subscribed; err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 3 || got[3] != 4 {
+		t.Errorf("got events %v, want [1 2 3 4]", got)
+	}
+}
@@ -0,0 +1,64 @@
+package frame
+
+import (
+	"sync/atomic"
+)
+
+// Subscription is the handle of a subscription made with Subscribe or SubscribeEventMessage.
+type Subscription struct {
+	key       EventSubKey
+	topicKey  interface{}
+	topicName string
+	cancel    func()
+	cancelled atomic.Bool
+}
+
+func (t *Subscription) GetKey() EventSubKey {
+	return t.key
+}
+
+// GetEventName returns the name of the event type or the payload type of the subscription.
+func (t *Subscription) GetEventName() string {
+	return t.topicName
+}
+
+// Cancel removes the subscription, the queued events of an asynchronous subscription are discarded.
+// It returns false if the subscription has already been cancelled.
+func (t *Subscription) Cancel() bool {
+	if !t.cancelled.CompareAndSwap(false, true) {
+		return false
+	}
+	t.cancel()
+	return true
+}
+
+func (t *Subscription) IsCancelled() bool {
+	return t.cancelled.Load()
+}
+
+// WithPriority sets the priority of the handler, handlers with a higher priority are called first,
+// and handlers with the same priority are called in the order of subscription. The default priority is 0.
+func WithPriority(priority int) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.priority = priority
+	}
+}
+
+// WithOnce cancels the subscription after the handler has received one event. A one-shot subscription to a retained
+// event, such as APPStartedEvent, fires once with the last retained event even when it is made after the publishing.
+func WithOnce() SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.once = true
+	}
+}
+
+// WithFilter delivers only the events for which the predicate returns true, the predicate is evaluated
+// on the publisher goroutine. The type E must be the payload type of the subscription, and several filters
+// of a subscription must all accept the event.
+func WithFilter[E any](predicate func(e E) bool) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		if predicate != nil {
+			opts.filters = append(opts.filters, predicate)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package frame
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testSubscriptionEvent struct {
+	Seq int
+}
+
+func TestSubscriptionOptions(t *testing.T) {
+	t.Run("priority", func(t *testing.T) {
+		var got []string
+		for _, sub := range []struct {
+			name     string
+			priority int
+		}{{"low", -1}, {"default", 0}, {"high", 10}, {"default_later", 0}} {
+			name := sub.name
+			subscription, err := Subscribe("test_priority", func(context.Context, testSubscriptionEvent) {
+				got = append(got, name)
+			}, WithPriority(sub.priority))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer subscription.Cancel()
+		}
+
+		_ = Publish(testSubscriptionEvent{})
+		if strings.Join(got, ",") != "high,default,default_later,low" {
+			t.Errorf("got handlers called in the order %v", got)
+		}
+	})
+
+	t.Run("once", func(t *testing.T) {
+		var calls int
+		subscription, err := Subscribe("test_once", func(context.Context, testSubscriptionEvent) {
+			calls++
+		}, WithOnce())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = Publish(testSubscriptionEvent{Seq: 1})
+		_ = Publish(testSubscriptionEvent{Seq: 2})
+		if calls != 1 {
+			t.Errorf("the handler was called %d times, want once", calls)
+		}
+		if !subscription.IsCancelled() {
+			t.Error("the one-shot subscription has not been cancelled")
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		var got []int
+		subscription, err := Subscribe("test_filter", func(_ context.Context, e testSubscriptionEvent) {
+			got = append(got, e.Seq)
+		}, WithFilter(func(e testSubscriptionEvent) bool { return e.Seq%2 == 0 }))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer subscription.Cancel()
+
+		for seq := 1; seq <= 4; seq++ {
+			_ = Publish(testSubscriptionEvent{Seq: seq})
+		}
+		if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+			t.Errorf("got events %v, want [2 4]", got)
+		}
+
+		if _, err := Subscribe("test_filter", func(context.Context, testSubscriptionEvent) {},
+			WithFilter(func(e testBusEvent) bool { return true })); err == nil {
+			t.Error("a filter of another payload type has been accepted")
+		}
+	})
+}
+
+func TestSubscriptionCancel(t *testing.T) {
+	var calls int
+	subscription, err := Subscribe("test_cancel", func(context.Context, testSubscriptionEvent) {
+		calls++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subscription.GetKey() != "test_cancel" || subscription.GetEventName() != "frame.testSubscriptionEvent" {
+		t.Errorf("got the subscription %v of %v", subscription.GetKey(), subscription.GetEventName())
+	}
+
+	if !subscription.Cancel() {
+		t.Fatal("cancelling the subscription failed")
+	}
+	if subscription.Cancel() {
+		t.Error("the subscription has been cancelled twice")
+	}
+	_ = Publish(testSubscriptionEvent{})
+	if calls != 0 {
+		t.Errorf("a cancelled handler was called %d times", calls)
+	}
+
+	// UnsubscribeEventMessage only cancels the subscriptions of the given event
+	const event, otherEvent EventType = 1001, 1002
+	var messages []EventType
+	for _, e := range []EventType{event, otherEvent} {
+		e := e
+		if err := SubscribeEventMessage(e, "test_cancel", func(args ...interface{}) {
+			messages = append(messages, e)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		UnsubscribeAll("test_cancel")
+	})
+	if err := UnsubscribeEventMessage(event, "test_cancel"); err != nil {
+		t.Fatal(err)
+	}
+	_ = PublishEventMessage(event)
+	_ = PublishEventMessage(otherEvent)
+	if len(messages) != 1 || messages[0] != otherEvent {
+		t.Errorf("got event messages %v, want only %v", messages, otherEvent)
+	}
+}
+
+func TestComponentSubscriptionsSurviveRestarts(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)})
+	ctx := context.Background()
+	var calls int
+	if _, err := Subscribe(ComponentID("store"), func(context.Context, testSubscriptionEvent) {
+		calls++
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A subscription made in Initialize must not be lost when the component restarts
+	if err := mgr.StopComponent(ctx, "store"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.RestartComponent(ctx, "store"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.RestartComponent(ctx, "store"); err != nil {
+		t.Fatal(err)
+	}
+	_ = Publish(testSubscriptionEvent{})
+	if calls != 1 {
+		t.Fatalf("the handler of a restarted component was called %d times, want 1", calls)
+	}
+
+	if err := mgr.RemoveComponent(ctx, "store"); err != nil {
+		t.Fatal(err)
+	}
+	_ = Publish(testSubscriptionEvent{})
+	if calls != 1 || len(eventBusInst.getSubscriptions(ComponentID("store"))) != 0 {
+		t.Errorf("the handler of a removed component was called %d times", calls-1)
+	}
+	takeTestMgrEvents()
+}
+
+func TestReplacementAndShutdownCancelComponentSubscriptions(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)})
+	subscribe := func() *Subscription {
+		sub, err := Subscribe(ComponentID("store"), func(context.Context, testSubscriptionEvent) {})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+
+	// The subscriptions of the old instance are cancelled once the new instance has replaced it
+	oldSub := subscribe()
+	if err := mgr.reconcile(context.Background(), []ComponentConfigModel{
+		{ID: "store", ComponentType: string(testMgrStoreType), Kw: map[string]interface{}{"fail_stop": false}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !oldSub.IsCancelled() {
+		t.Error("the subscription of a replaced component has not been cancelled")
+	}
+
+	subscribe()
+	if err := mgr.stopAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(eventBusInst.getSubscriptions(ComponentID("store"))); got != 0 {
+		t.Errorf("got %d subscriptions of the component after the shutdown, want 0", got)
+	}
+	takeTestMgrEvents()
+}
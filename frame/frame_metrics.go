@@ -0,0 +1,93 @@
+package frame
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+const (
+	metricsNamespace = "micro_app"
+)
+
+var (
+	componentStartDurationGauge   = mustRegisterGaugeVec("component_start_duration_seconds", "Duration of the last startup of each component.", "component_id", "component_type")
+	componentStatusGauge          = mustRegisterGaugeVec("component_status", "Lifecycle status of each component, 1 for the current status.", "component_id", "component_type", "status")
+	configReloadCounter           = mustRegisterCounterVec("config_reload_total", "Number of successful configuration reloads.", "key")
+	configReloadFailureCounter    = mustRegisterCounterVec("config_reload_failures_total", "Number of failed configuration reloads.", "key")
+	configVersionGauge            = mustRegisterGaugeVec("config_version", "Current version of each configuration.", "key")
+	eventPublishCounter           = mustRegisterCounterVec("event_publish_total", "Number of published event messages.", "event")
+	eventHandlerDurationHistogram = mustRegisterHistogramVec("event_handler_duration_seconds", "Latency of event message handlers.", "event")
+	eventDeliveredCounter         = mustRegisterCounterVec("event_delivered_total", "Number of events handled by subscribers.", "event", "mode")
+	eventDroppedCounter           = mustRegisterCounterVec("event_dropped_total", "Number of events dropped because of full queues.", "event", "policy")
+	eventHandlerPanicCounter      = mustRegisterCounterVec("event_handler_panics_total", "Number of recovered panics of event handlers.", "event")
+	subProcessStartCounter        = mustRegisterCounterVec("subprocess_starts_total", "Number of sub process starts.", "result")
+	subProcessExitCounter         = mustRegisterCounter("subprocess_exits_total", "Number of sub process exits.")
+	memStatsGauge                 = mustRegisterGaugeVec("memstats_bytes", "Memory statistics of the Go runtime.", "stat")
+	gcCountGauge                  = mustRegisterGauge("gc_count", "Number of completed GC cycles.")
+	gcPauseTotalGauge             = mustRegisterGauge("gc_pause_seconds_total", "Cumulative GC pause duration.")
+	goroutinesGauge               = mustRegisterGauge("goroutines", "Number of goroutines.")
+	memoryLimitGauge              = mustRegisterGauge("memory_limit_bytes", "Soft memory limit of the Go runtime.")
+)
+
+func init() {
+	GetMetricsRegistry().RegisterCollector(collectComponentMetrics)
+	GetMetricsRegistry().RegisterCollector(collectRuntimeMetrics)
+}
+
+func collectComponentMetrics() {
+	componentStatusGauge.Reset()
+	for _, component := range GetComponentMgr().GetComponentList() {
+		componentStatusGauge.WithLabelValues(string(component.GetID()), string(component.GetType()),
+			component.GetStatus().String()).Set(1)
+	}
+}
+
+func collectRuntimeMetrics() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	memStatsGauge.WithLabelValues("alloc").Set(float64(stats.Alloc))
+	memStatsGauge.WithLabelValues("sys").Set(float64(stats.Sys))
+	memStatsGauge.WithLabelValues("heap_alloc").Set(float64(stats.HeapAlloc))
+	memStatsGauge.WithLabelValues("heap_sys").Set(float64(stats.HeapSys))
+	memStatsGauge.WithLabelValues("heap_idle").Set(float64(stats.HeapIdle))
+	memStatsGauge.WithLabelValues("heap_released").Set(float64(stats.HeapReleased))
+	memStatsGauge.WithLabelValues("stack_sys").Set(float64(stats.StackSys))
+	memStatsGauge.WithLabelValues("next_gc").Set(float64(stats.NextGC))
+	gcCountGauge.Set(float64(stats.NumGC))
+	gcPauseTotalGauge.Set(float64(stats.PauseTotalNs) / 1e9)
+	goroutinesGauge.Set(float64(runtime.NumGoroutine()))
+	memoryLimitGauge.Set(float64(debug.SetMemoryLimit(-1)))
+}
+
+func mustRegisterCounterVec(name, help string, labelNames ...string) *CounterVec {
+	vec, err := GetMetricsRegistry().RegisterCounterVec(metricsNamespace+"_"+name, help, labelNames...)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
+
+func mustRegisterGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	vec, err := GetMetricsRegistry().RegisterGaugeVec(metricsNamespace+"_"+name, help, labelNames...)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
+
+func mustRegisterHistogramVec(name, help string, labelNames ...string) *HistogramVec {
+	vec, err := GetMetricsRegistry().RegisterHistogramVec(metricsNamespace+"_"+name, help, nil, labelNames...)
+	if err != nil {
+		panic(err)
+	}
+	return vec
+}
+
+func mustRegisterCounter(name, help string) *Counter {
+	return mustRegisterCounterVec(name, help).WithLabelValues()
+}
+
+func mustRegisterGauge(name, help string) *Gauge {
+	return mustRegisterGaugeVec(name, help).WithLabelValues()
+}
@@ -0,0 +1,252 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akley-MK4/go-tools-box/ctime"
+)
+
+const (
+	defaultHealthCheckIntervalSec = 10
+	defaultHealthCheckTimeoutSec  = 5
+)
+
+type HealthState uint8
+
+const (
+	HealthStateUnknown HealthState = iota
+	HealthStateHealthy
+	HealthStateDegraded
+	HealthStateUnhealthy
+)
+
+var (
+	healthStateDescMap = map[HealthState]string{
+		HealthStateUnknown:   "Unknown",
+		HealthStateHealthy:   "Healthy",
+		HealthStateDegraded:  "Degraded",
+		HealthStateUnhealthy: "Unhealthy",
+	}
+
+	healthMgr = &HealthMgr{
+		resultMap: make(map[ComponentID]componentHealthResult),
+	}
+)
+
+func (t HealthState) String() string {
+	desc, exist := healthStateDescMap[t]
+	if !exist {
+		return fmt.Sprintf("Unknown(%d)", uint8(t))
+	}
+	return desc
+}
+
+func (t HealthState) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+type HealthStatus struct {
+	State   HealthState
+	Message string
+}
+
+// IHealthChecker is an optional interface for components. The frame calls CheckHealth periodically
+// while the component is running, the context is bounded by the health check timeout.
+type IHealthChecker interface {
+	CheckHealth(ctx context.Context) HealthStatus
+}
+
+type HealthCheckConfig struct {
+	IntervalSec uint64 `json:"interval_sec"`
+	TimeoutSec  uint64 `json:"timeout_sec"`
+}
+
+type ComponentHealthInfo struct {
+	ID             ComponentID
+	Type           ComponentType
+	Disabled       bool
+	Status         ComponentStatus
+	State          HealthState
+	Message        string
+	CheckTimestamp int64
+}
+
+type AppHealthInfo struct {
+	State           HealthState
+	UpdateTimestamp int64
+	Components      []ComponentHealthInfo
+}
+
+type componentHealthResult struct {
+	status         HealthStatus
+	checkTimestamp int64
+}
+
+func GetHealthMgr() *HealthMgr {
+	return healthMgr
+}
+
+// HealthMgr polls the components that implement IHealthChecker and aggregates their health
+// with the status of every component into the health of the application.
+type HealthMgr struct {
+	mutex           sync.RWMutex
+	interval        time.Duration
+	timeout         time.Duration
+	resultMap       map[ComponentID]componentHealthResult
+	updateTimestamp int64
+	cancel          context.CancelFunc
+	// progress is the last time in nanoseconds at which the loop of the health checks made progress,
+	// 0 if the loop is not running
+	progress atomic.Int64
+}
+
+func (t *HealthMgr) initialize(cfg HealthCheckConfig) {
+	t.interval = time.Duration(cfg.IntervalSec) * time.Second
+	if t.interval <= 0 {
+		t.interval = time.Second * defaultHealthCheckIntervalSec
+	}
+	t.timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	if t.timeout <= 0 {
+		t.timeout = time.Second * defaultHealthCheckTimeoutSec
+	}
+}
+
+func (t *HealthMgr) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.mutex.Lock()
+	t.cancel = cancel
+	t.mutex.Unlock()
+
+	t.progress.Store(time.Now().UnixNano())
+	go t.loopCheck(ctx)
+}
+
+func (t *HealthMgr) stop() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	t.progress.Store(0)
+}
+
+// getStalledDuration returns how long the loop of the health checks has made no progress beyond the time a round
+// may take to start and to check a component, 0 if it is making progress or not running.
+func (t *HealthMgr) getStalledDuration() time.Duration {
+	progress := t.progress.Load()
+	if progress == 0 {
+		return 0
+	}
+	stalled := time.Since(time.Unix(0, progress)) - t.interval - t.timeout
+	if stalled < 0 {
+		return 0
+	}
+	return stalled
+}
+
+func (t *HealthMgr) loopCheck(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.progress.Store(time.Now().UnixNano())
+		t.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *HealthMgr) checkAll(ctx context.Context) {
+	resultMap := make(map[ComponentID]componentHealthResult)
+	for _, component := range GetComponentMgr().GetComponentList() {
+		checker, ok := component.(IHealthChecker)
+		if !ok || component.GetStatus() != ComponentRunningStatus {
+			continue
+		}
+
+		statusChan := make(chan HealthStatus, 1)
+		err := runWithTimeout(ctx, t.timeout, func(ctx context.Context) error {
+			statusChan <- checker.CheckHealth(ctx)
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		var status HealthStatus
+		if err != nil {
+			status = HealthStatus{State: HealthStateUnhealthy, Message: fmt.Sprintf("health check failed, %v", err)}
+		} else {
+			status = <-statusChan
+		}
+		resultMap[component.GetID()] = componentHealthResult{status: status, checkTimestamp: ctime.CurrentTimestamp()}
+		t.progress.Store(time.Now().UnixNano())
+	}
+
+	t.mutex.Lock()
+	t.resultMap = resultMap
+	t.updateTimestamp = ctime.CurrentTimestamp()
+	t.mutex.Unlock()
+}
+
+// GetAppHealthInfo aggregates the last health check results and the current status of every component.
+// A component that is not running is degraded, or unhealthy if it failed. The application takes the worst state
+// of its components, disabled components are reported but not counted.
+func (t *HealthMgr) GetAppHealthInfo() (retInfo AppHealthInfo) {
+	t.mutex.RLock()
+	retInfo.UpdateTimestamp = t.updateTimestamp
+	resultMap := t.resultMap
+	t.mutex.RUnlock()
+
+	retInfo.State = HealthStateHealthy
+	for _, component := range GetComponentMgr().GetComponentList() {
+		info := ComponentHealthInfo{
+			ID:     component.GetID(),
+			Type:   component.GetType(),
+			Status: component.GetStatus(),
+		}
+
+		switch info.Status {
+		case ComponentRunningStatus:
+			info.State = HealthStateHealthy
+			if result, exist := resultMap[info.ID]; exist {
+				info.State = result.status.State
+				info.Message = result.status.Message
+				info.CheckTimestamp = result.checkTimestamp
+			}
+		case ComponentFailedStatus:
+			info.State = HealthStateUnhealthy
+			info.Message = component.GetStatusInfo().LastError
+		default:
+			info.State = HealthStateDegraded
+			info.Message = fmt.Sprintf("the component is %v", info.Status)
+		}
+
+		if info.State > retInfo.State {
+			retInfo.State = info.State
+		}
+		retInfo.Components = append(retInfo.Components, info)
+	}
+
+	var disabledList []ComponentHealthInfo
+	for id, tpy := range GetComponentMgr().getDisabledComponents() {
+		disabledList = append(disabledList, ComponentHealthInfo{
+			ID: id, Type: tpy, Disabled: true, State: HealthStateUnknown, Message: "the component is disabled",
+		})
+	}
+	sort.Slice(disabledList, func(i, j int) bool {
+		return disabledList[i].ID < disabledList[j].ID
+	})
+	retInfo.Components = append(retInfo.Components, disabledList...)
+
+	return
+}
@@ -0,0 +1,111 @@
+package frame
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHealthType ComponentType = "TestHealth"
+
+type testHealthKW struct {
+	State   HealthState `json:"state"`
+	Message string      `json:"message"`
+	Hang    bool        `json:"hang"`
+}
+
+type testHealthComponent struct {
+	BaseComponent
+	kw *testHealthKW
+}
+
+func (t *testHealthComponent) Initialize(kw IComponentKW) error {
+	t.kw = kw.(*testHealthKW)
+	return nil
+}
+
+func (t *testHealthComponent) CheckHealth(ctx context.Context) HealthStatus {
+	if t.kw.Hang {
+		<-ctx.Done()
+	}
+	return HealthStatus{State: t.kw.State, Message: t.kw.Message}
+}
+
+func init() {
+	RegisterComponentInfo(ComponentPriorityGeneral, testHealthType, func() IComponent { return &testHealthComponent{} },
+		func() IComponentKW { return &testHealthKW{} })
+}
+
+// useTestComponentMgr makes the manager the one returned by GetComponentMgr until the test ends.
+func useTestComponentMgr(t *testing.T, mgr *ComponentMgr) {
+	prevMgr := componentMgr
+	componentMgr = mgr
+	t.Cleanup(func() {
+		componentMgr = prevMgr
+	})
+}
+
+func TestHealthMgrAggregatesComponents(t *testing.T) {
+	mgr := newTestComponentMgr(t,
+		ComponentConfigModel{ID: "healthy", ComponentType: string(testHealthType),
+			Kw: map[string]interface{}{"state": int(HealthStateHealthy)}},
+		ComponentConfigModel{ID: "degraded", ComponentType: string(testHealthType),
+			Kw: map[string]interface{}{"state": int(HealthStateDegraded), "message": "slow"}},
+		ComponentConfigModel{ID: "plain", ComponentType: string(testMgrStoreType)},
+		ComponentConfigModel{ID: "off", ComponentType: string(testMgrStoreType), Disable: true},
+	)
+	useTestComponentMgr(t, mgr)
+
+	hm := &HealthMgr{}
+	hm.initialize(HealthCheckConfig{})
+	hm.checkAll(context.Background())
+
+	info := hm.GetAppHealthInfo()
+	if info.State != HealthStateDegraded || info.UpdateTimestamp == 0 {
+		t.Fatalf("got application health %v at %d, want Degraded", info.State, info.UpdateTimestamp)
+	}
+	got := make(map[ComponentID]ComponentHealthInfo)
+	for _, component := range info.Components {
+		got[component.ID] = component
+	}
+	if c := got["degraded"]; c.State != HealthStateDegraded || c.Message != "slow" || c.CheckTimestamp == 0 {
+		t.Errorf("got %+v for the degraded component", c)
+	}
+	if c := got["plain"]; c.State != HealthStateHealthy || c.CheckTimestamp != 0 {
+		t.Errorf("got %+v for a running component without a health check", c)
+	}
+	if c := got["off"]; !c.Disabled || c.State != HealthStateUnknown {
+		t.Errorf("got %+v for the disabled component", c)
+	}
+
+	// A stopped component degrades the application even if its last check was healthy
+	if err := mgr.StopComponent(context.Background(), "healthy"); err != nil {
+		t.Fatal(err)
+	}
+	info = hm.GetAppHealthInfo()
+	if info.State != HealthStateDegraded || info.Components[0].Message != "the component is Stopped" {
+		t.Fatalf("got %+v, want the stopped component degraded", info)
+	}
+}
+
+func TestHealthMgrCheckTimeout(t *testing.T) {
+	mgr := newTestComponentMgr(t, ComponentConfigModel{ID: "hang", ComponentType: string(testHealthType),
+		Kw: map[string]interface{}{"state": int(HealthStateHealthy), "hang": true}})
+	useTestComponentMgr(t, mgr)
+
+	hm := &HealthMgr{}
+	hm.initialize(HealthCheckConfig{})
+	hm.timeout = 20 * time.Millisecond
+
+	begin := time.Now()
+	hm.checkAll(context.Background())
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Fatalf("the health check took %v", elapsed)
+	}
+
+	info := hm.GetAppHealthInfo()
+	if info.State != HealthStateUnhealthy || !strings.Contains(info.Components[0].Message, "health check failed") {
+		t.Fatalf("got %+v, want an unhealthy component after the timeout", info)
+	}
+}
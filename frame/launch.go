@@ -1,26 +1,32 @@
 package frame
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"strings"
-	"syscall"
+	"sync/atomic"
 	"time"
-
-	ossignal "github.com/akley-MK4/go-tools-box/signal"
 )
 
 const (
-	waitStartSubProcSec = 5
-	defaultSignChanSize = 1
+	waitStartSubProcSec       = 5
+	defaultSignChanSize       = 1
+	defaultShutdownTimeoutSec = 30
 )
 
-type componentConfigModel struct {
-	ComponentType string                 `json:"component_type"`
-	Disable       bool                   `json:"disable"`
-	Kw            map[string]interface{} `json:"kw"`
+type ComponentConfigModel struct {
+	// ID is optional and must be unique, <component_type>_<index> is generated if it is empty
+	ID            string   `json:"id"`
+	ComponentType string   `json:"component_type"`
+	Disable       bool     `json:"disable"`
+	DependsOn     []string `json:"depends_on"`
+	// StartTimeoutSec and StopTimeoutSec bound the startup and shutdown of the component, 0 means no limit
+	StartTimeoutSec uint64                 `json:"start_timeout_sec"`
+	StopTimeoutSec  uint64                 `json:"stop_timeout_sec"`
+	Kw              map[string]interface{} `json:"kw"`
 }
 
 type configInfoModel struct {
@@ -28,6 +34,10 @@ type configInfoModel struct {
 	Path                  string `json:"path"`
 	EnableWatchLog        bool   `json:"enableWatchLog"`
 	RetryWatchIntervalSec uint64 `json:"retryWatchIntervalSec"`
+	// DebounceIntervalMs is the settle window that folds a burst of file events into one reload
+	DebounceIntervalMs uint64 `json:"debounceIntervalMs"`
+	// Format overrides the format chosen by the file extension, one of json, jsonc, json5, yaml, toml and ini
+	Format string `json:"format"`
 }
 
 type GCControl struct {
@@ -53,10 +63,32 @@ type LauncherConfigModel struct {
 	GCControl      GCControl              `json:"gc_control"`
 	ConfigInfoList []*configInfoModel     `json:"configs"`
 	SubProcessList SubProcessList         `json:"sub_process_list"`
-	Components     []componentConfigModel `json:"components"`
+	Components     []ComponentConfigModel `json:"components"`
+	HealthCheck    HealthCheckConfig      `json:"health_check"`
+	Admin          AdminConfig            `json:"admin"`
+	Metrics        MetricsConfig          `json:"metrics"`
+	Probes         ProbeConfig            `json:"probes"`
+	// WatchLauncherConfig reconciles the running components whenever the launcher configuration file changes
+	WatchLauncherConfig bool `json:"watch_launcher_config"`
+	// ShutdownDrainSec is the period to wait after the application is marked not ready before components stop
+	ShutdownDrainSec uint64 `json:"shutdown_drain_sec"`
+	// ShutdownTimeoutSec bounds the shutdown of all components, defaultShutdownTimeoutSec is used if it is 0
+	ShutdownTimeoutSec uint64 `json:"shutdown_timeout_sec"`
+}
+
+var (
+	appStartupDone atomic.Bool
+	appStarted     atomic.Bool
+	appStopping    atomic.Bool
+)
+
+// IsAppStarted reports whether the application has been launched and is not stopping.
+func IsAppStarted() bool {
+	return appStarted.Load()
 }
 
 func LaunchDaemonApplication(processType ProcessType, workPath string, launchConf string, appArgs []interface{}, enabledDevMode bool) error {
+	launchBegin := time.Now()
 	getLoggerInst().InfoF("Execution parameters: %v", strings.Join(os.Args, " "))
 
 	if sysVer, errSysVer := os.ReadFile("/proc/version"); errSysVer != nil {
@@ -83,7 +115,7 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 		return fmt.Errorf("unable to load the startup configuration, %v", readFileErr)
 	}
 	launcherConf := &LauncherConfigModel{}
-	if err := json.Unmarshal(fileData, launcherConf); err != nil {
+	if err := DecodeConfigData(GetConfigFormatByPath(launchConf), fileData, launcherConf); err != nil {
 		return fmt.Errorf("unable to unmarshal the startup configuration, %v", err)
 	}
 	getLoggerInst().InfoF("The content of the startup configuration loaded from path %v is as follows", launchConf)
@@ -95,6 +127,12 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 	if launcherConf.AppID == "" {
 		return fmt.Errorf("invalid app id")
 	}
+	currentAppID = launcherConf.AppID
+	shutdownTimeout := time.Duration(launcherConf.ShutdownTimeoutSec) * time.Second
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = time.Second * defaultShutdownTimeoutSec
+	}
+
 	pidFileDirPath := launcherConf.PidFileDirPath
 	if pidFileDirPath == "" {
 		pidFileDirPath = workPath
@@ -106,15 +144,7 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 	getLoggerInst().InfoF("The current process id is %d, and the file path is %s", pid, pidFilePath)
 
 	// Set signal handler
-	signalHandler := &ossignal.Handler{}
-	if err := signalHandler.InitSignalHandler(defaultSignChanSize); err != nil {
-		return err
-	}
-	for _, sig := range []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT} {
-		signalHandler.RegisterSignal(sig, func() {
-			signalHandler.CloseSignalHandler()
-		})
-	}
+	signalHandler := newShutdownSignalHandler()
 
 	// log level
 	getLoggerInst().SetLevelByDesc(launcherConf.LogLevel)
@@ -122,40 +152,59 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 	// Set memory garbage collection policy
 	setGCPolicy(launcherConf.GCControl)
 
-	// Initialize the event message manager
-	if err := initializeEventMessageMgr(); err != nil {
-		return fmt.Errorf("unable to initialize EventMessageMgr, %v", err)
+	// Start the probe server before anything else, so that the startup can be observed
+	if launcherConf.Probes.Enable {
+		if err := probeServerInst.start(launcherConf.Probes); err != nil {
+			return rollbackLaunch(fmt.Errorf("unable to start the probe server, %v", err), pidFilePath, shutdownTimeout)
+		}
 	}
 
 	// Initialize and start the configuration watcher manager
 	if err := GetConfigWatcherMgr().initialize(workPath, launcherConf.ConfigInfoList, enabledDevMode); err != nil {
-		return fmt.Errorf("unable to initialize configuration watcher manager, %v", err)
+		return rollbackLaunch(fmt.Errorf("unable to initialize configuration watcher manager, %v", err),
+			pidFilePath, shutdownTimeout)
 	}
 	GetConfigWatcherMgr().start()
 
 	getLoggerInst().Info("Initialized the application")
 
-	// Initialize and start all components
-	var components []IComponent
-	for componentIdx, cfg := range launcherConf.Components {
-		if cfg.Disable {
-			continue
+	// Initialize and start all components in the order of their dependencies
+	if err := GetComponentMgr().initialize(launcherConf.Components); err != nil {
+		return rollbackLaunch(err, pidFilePath, shutdownTimeout)
+	}
+	getLoggerInst().Info("Successfully created and initialized all components")
+
+	if err := GetComponentMgr().startAll(context.Background()); err != nil {
+		return rollbackLaunch(err, pidFilePath, shutdownTimeout)
+	}
+	getLoggerInst().Info("Successfully started all components")
+
+	// Poll the health of components
+	GetHealthMgr().initialize(launcherConf.HealthCheck)
+	GetHealthMgr().start()
+
+	// Start the metrics server
+	if launcherConf.Metrics.Enable {
+		if err := metricsServerInst.start(launcherConf.Metrics); err != nil {
+			return rollbackLaunch(fmt.Errorf("unable to start the metrics server, %v", err), pidFilePath, shutdownTimeout)
 		}
-		if component, err := createAndInitializeComponent(componentIdx, cfg); err != nil {
-			return fmt.Errorf("unable to create and initialize component type %v, ComponentIndex: %v, Error: %v", cfg.ComponentType, componentIdx, err)
-		} else {
-			components = append(components, component)
+	}
+
+	// Start the admin server
+	if launcherConf.Admin.Enable {
+		if err := adminServerInst.start(launcherConf.Admin); err != nil {
+			return rollbackLaunch(fmt.Errorf("unable to start the admin server, %v", err), pidFilePath, shutdownTimeout)
 		}
 	}
-	getLoggerInst().Info("Successfully created and initialized all components")
 
-	for _, component := range components {
-		if err := component.Start(); err != nil {
-			return fmt.Errorf("unable to start component %v, %v", component.GetID(), err)
+	// Watch the launcher configuration and reconcile the components against it
+	if launcherConf.WatchLauncherConfig {
+		if err := watchLauncherConfig(launchConf); err != nil {
+			return rollbackLaunch(fmt.Errorf("unable to watch the launcher configuration, %v", err),
+				pidFilePath, shutdownTimeout)
 		}
-		getLoggerInst().InfoF("The component %v has started", component.GetID())
+		getLoggerInst().InfoF("Watching the launcher configuration %v", launchConf)
 	}
-	getLoggerInst().Info("Successfully started all components")
 
 	// Check and create all child processes
 	if launcherConf.SubProcessList.Enable {
@@ -163,12 +212,13 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 		time.Sleep(time.Second * waitStartSubProcSec)
 
 		for _, kwArgs := range launcherConf.SubProcessList.Commands {
-			_, argsStr, newCmdErr := startSubprocess(os.Args[0], kwArgs)
+			cmd, argsStr, newCmdErr := startSubprocess(os.Args[0], kwArgs)
 			if newCmdErr != nil {
 				getLoggerInst().WarningF("Failed to start sub process, Args: %s, Err: %v", argsStr, newCmdErr)
 				continue
 			}
 			getLoggerInst().InfoF("Created a sub process, Args: %s", argsStr)
+			go waitSubprocess(cmd)
 		}
 	}
 
@@ -179,20 +229,13 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 	getLoggerInst().Info("The current memory usage information of the application is as follows")
 	fmt.Println(GetInitialMemorySnapshot())
 
-	PublishEventMessage(EventAPPStarted)
+	publishFrameEvent(EventAPPStarted, APPStartedEvent{AppID: currentAppID, StartupDuration: time.Since(launchBegin)})
+	appStartupDone.Store(true)
+	appStarted.Store(true)
 
-	signalHandler.ListenSignal()
-	// Stop process
-	getLoggerInst().Info("Stopping the application")
-
-	for _, component := range components {
-		if err := component.Stop(); err != nil {
-			getLoggerInst().WarningF("Failed to stop component %v, %v", component.GetID(), err)
-			continue
-		}
-		getLoggerInst().InfoF("The component %v has stopped", component.GetID())
-	}
-	getLoggerInst().Info("Stopped all components")
+	sig := signalHandler.waitSignal(pidFilePath)
+	shutdownApplication(sig, time.Duration(launcherConf.ShutdownDrainSec)*time.Second, shutdownTimeout)
+	signalHandler.stop()
 
 	if err := deleteProcessIdFile(pidFilePath); err != nil {
 		getLoggerInst().WarningF("Failed to delete the process id file, %v", err)
@@ -204,3 +247,39 @@ func LaunchDaemonApplication(processType ProcessType, workPath string, launchCon
 
 	return nil
 }
+
+// rollbackLaunch undoes a failed launch. It stops the components that have already started in the reverse order,
+// stops the configuration watcher manager and deletes the process id file.
+// The returned error aggregates the original failure and any errors that occurred during the rollback.
+func rollbackLaunch(cause error, pidFilePath string, shutdownTimeout time.Duration) error {
+	getLoggerInst().WarningF("Failed to launch the application, rolling back, %v", cause)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	errs := []error{cause}
+	GetHealthMgr().stop()
+	if err := GetComponentMgr().stopAll(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to stop components during rollback, %v", err))
+	}
+
+	if err := adminServerInst.stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to stop the admin server during rollback, %v", err))
+	}
+	if err := metricsServerInst.stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to stop the metrics server during rollback, %v", err))
+	}
+	if err := probeServerInst.stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("unable to stop the probe server during rollback, %v", err))
+	}
+
+	if err := GetConfigWatcherMgr().stop(); err != nil {
+		errs = append(errs, fmt.Errorf("unable to stop configuration watcher manager during rollback, %v", err))
+	}
+
+	if err := deleteProcessIdFile(pidFilePath); err != nil {
+		errs = append(errs, fmt.Errorf("unable to delete the process id file during rollback, %v", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -19,7 +19,35 @@ type ForcePolicy struct {
 	MemPeak    int `json:"mem_peak"`
 }
 
+var (
+	currentGCControl GCControl
+)
+
+// GCSettings describes the garbage collection policy of the application and the latest collection statistics
+type GCSettings struct {
+	GCControl             GCControl
+	MemoryUsageLimitBytes int64
+	NumGC                 uint32
+	NumForcedGC           uint32
+	LastGCTimestamp       int64
+	PauseTotalNs          uint64
+}
+
+func GetGCSettings() (retSettings GCSettings) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	retSettings.GCControl = currentGCControl
+	retSettings.MemoryUsageLimitBytes = debug.SetMemoryLimit(-1)
+	retSettings.NumGC = stats.NumGC
+	retSettings.NumForcedGC = stats.NumForcedGC
+	retSettings.LastGCTimestamp = int64(stats.LastGC / uint64(time.Second))
+	retSettings.PauseTotalNs = stats.PauseTotalNs
+	return
+}
+
 func setGCPolicy(ctrl GCControl) {
+	currentGCControl = ctrl
 	if ctrl.Percent > 0 {
 		debug.SetGCPercent(ctrl.Percent)
 		getLoggerInst().InfoF("Set GCPercentage to %d", ctrl.Percent)
@@ -39,7 +67,7 @@ func setGCPolicy(ctrl GCControl) {
 	go func() {
 		for {
 			time.Sleep(time.Duration(ctrl.ForcePolicy.IntervalSecondS) * time.Second)
-			runtime.GC()
+			forceGC("policy")
 		}
 	}()
 
@@ -57,6 +85,21 @@ func UpdateMemoryUsageLimitBytes(limitBytes int64) {
 	beforeLimitBytes := debug.SetMemoryLimit(limitBytes)
 	getLoggerInst().InfoF("The usage limit for memory size has been updated from %d to %d",
 		beforeLimitBytes, limitBytes)
+	if beforeLimitBytes != limitBytes {
+		publishFrameEvent(EventMemoryLimitChanged, MemoryLimitChangedEvent{
+			BeforeLimitBytes: beforeLimitBytes,
+			LimitBytes:       limitBytes,
+		})
+	}
+}
+
+// forceGC runs a GC immediately and publishes EventGCForced.
+func forceGC(reason string) time.Duration {
+	begin := time.Now()
+	runtime.GC()
+	duration := time.Since(begin)
+	publishFrameEvent(EventGCForced, GCForcedEvent{Reason: reason, Duration: duration})
+	return duration
 }
 
 func NewMemorySnapshot() *MemorySnapshot {
@@ -73,21 +116,21 @@ func (t *MemorySnapshot) GetStats() runtime.MemStats {
 	return t.stats
 }
 
-func (t *MemorySnapshot) String() string {
-	type Info struct {
-		Alloc       string
-		TotalAlloc  string
-		Sys         string
-		Mallocs     string
-		HeapAlloc   string
-		HeapSys     string
-		HeapObjects uint64
-		StackSys    string
-		MSpanSys    string
-		MCacheSys   string
-	}
+type memorySnapshotInfo struct {
+	Alloc       string
+	TotalAlloc  string
+	Sys         string
+	Mallocs     string
+	HeapAlloc   string
+	HeapSys     string
+	HeapObjects uint64
+	StackSys    string
+	MSpanSys    string
+	MCacheSys   string
+}
 
-	info := Info{
+func (t *MemorySnapshot) getInfo() memorySnapshotInfo {
+	return memorySnapshotInfo{
 		Alloc:       MemorySizeToString(t.stats.Alloc),
 		TotalAlloc:  MemorySizeToString(t.stats.TotalAlloc),
 		Sys:         MemorySizeToString(t.stats.Sys),
@@ -99,8 +142,14 @@ func (t *MemorySnapshot) String() string {
 		MSpanSys:    MemorySizeToString(t.stats.MSpanSys),
 		MCacheSys:   MemorySizeToString(t.stats.MCacheSys),
 	}
+}
+
+func (t *MemorySnapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.getInfo())
+}
 
-	d, err := json.MarshalIndent(info, "", " ")
+func (t *MemorySnapshot) String() string {
+	d, err := json.MarshalIndent(t.getInfo(), "", " ")
 	if err != nil {
 		return err.Error()
 	}
@@ -0,0 +1,496 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeHistogram MetricType = "histogram"
+
+	defaultMetricsAddr = "127.0.0.1:9091"
+	defaultMetricsPath = "/metrics"
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type MetricType string
+
+var (
+	DefaultHistogramBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	metricNameRegexp  = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	metricLabelRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	metricsRegistryInst = NewMetricsRegistry()
+	metricsServerInst   = &metricsServer{}
+)
+
+// GetMetricsRegistry returns the registry that the frame exports its own metrics with,
+// components can register their metrics with it as well.
+func GetMetricsRegistry() *MetricsRegistry {
+	return metricsRegistryInst
+}
+
+// MetricsConfig configures the listener that exposes the metrics in Prometheus text format
+type MetricsConfig struct {
+	Enable bool   `json:"enable"`
+	Addr   string `json:"addr"`
+	Path   string `json:"path"`
+}
+
+type atomicFloat64 struct {
+	bits atomic.Uint64
+}
+
+func (t *atomicFloat64) Load() float64 {
+	return math.Float64frombits(t.bits.Load())
+}
+
+func (t *atomicFloat64) Store(v float64) {
+	t.bits.Store(math.Float64bits(v))
+}
+
+func (t *atomicFloat64) Add(v float64) {
+	for {
+		oldBits := t.bits.Load()
+		newBits := math.Float64bits(math.Float64frombits(oldBits) + v)
+		if t.bits.CompareAndSwap(oldBits, newBits) {
+			return
+		}
+	}
+}
+
+type Counter struct {
+	val atomicFloat64
+}
+
+func (t *Counter) Inc() {
+	t.val.Add(1)
+}
+
+// Add increases the counter, negative values are ignored.
+func (t *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	t.val.Add(v)
+}
+
+func (t *Counter) Value() float64 {
+	return t.val.Load()
+}
+
+type Gauge struct {
+	val atomicFloat64
+}
+
+func (t *Gauge) Set(v float64) {
+	t.val.Store(v)
+}
+
+func (t *Gauge) Add(v float64) {
+	t.val.Add(v)
+}
+
+func (t *Gauge) Inc() {
+	t.val.Add(1)
+}
+
+func (t *Gauge) Dec() {
+	t.val.Add(-1)
+}
+
+func (t *Gauge) Value() float64 {
+	return t.val.Load()
+}
+
+type Histogram struct {
+	upperBounds []float64
+	counts      []atomic.Uint64
+	count       atomic.Uint64
+	sum         atomicFloat64
+}
+
+func newHistogram(upperBounds []float64) *Histogram {
+	return &Histogram{
+		upperBounds: upperBounds,
+		counts:      make([]atomic.Uint64, len(upperBounds)),
+	}
+}
+
+func (t *Histogram) Observe(v float64) {
+	idx := sort.SearchFloat64s(t.upperBounds, v)
+	if idx < len(t.counts) {
+		t.counts[idx].Add(1)
+	}
+	t.count.Add(1)
+	t.sum.Add(v)
+}
+
+type metricChild struct {
+	labelValues []string
+	counter     *Counter
+	gauge       *Gauge
+	histogram   *Histogram
+}
+
+type metricFamily struct {
+	name        string
+	help        string
+	tpy         MetricType
+	labelNames  []string
+	upperBounds []float64
+	gaugeFunc   func() float64
+
+	mutex    sync.RWMutex
+	children map[string]*metricChild
+}
+
+func (t *metricFamily) getChild(labelValues []string) *metricChild {
+	if len(labelValues) != len(t.labelNames) {
+		panic(fmt.Sprintf("metric %v expects %d label values, got %d", t.name, len(t.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	t.mutex.RLock()
+	child, exist := t.children[key]
+	t.mutex.RUnlock()
+	if exist {
+		return child
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if child, exist = t.children[key]; exist {
+		return child
+	}
+
+	child = &metricChild{labelValues: append([]string{}, labelValues...)}
+	switch t.tpy {
+	case MetricTypeCounter:
+		child.counter = &Counter{}
+	case MetricTypeGauge:
+		child.gauge = &Gauge{}
+	case MetricTypeHistogram:
+		child.histogram = newHistogram(t.upperBounds)
+	}
+	t.children[key] = child
+	return child
+}
+
+func (t *metricFamily) deleteChild(labelValues []string) {
+	t.mutex.Lock()
+	delete(t.children, strings.Join(labelValues, "\xff"))
+	t.mutex.Unlock()
+}
+
+type CounterVec struct {
+	family *metricFamily
+}
+
+// WithLabelValues returns the counter of the label values, it is created on first use.
+// Callers on a hot path should keep the returned counter instead of looking it up on every call.
+func (t *CounterVec) WithLabelValues(labelValues ...string) *Counter {
+	return t.family.getChild(labelValues).counter
+}
+
+func (t *CounterVec) DeleteLabelValues(labelValues ...string) {
+	t.family.deleteChild(labelValues)
+}
+
+type GaugeVec struct {
+	family *metricFamily
+}
+
+func (t *GaugeVec) WithLabelValues(labelValues ...string) *Gauge {
+	return t.family.getChild(labelValues).gauge
+}
+
+func (t *GaugeVec) DeleteLabelValues(labelValues ...string) {
+	t.family.deleteChild(labelValues)
+}
+
+// Reset removes all label values, it is used by gauges that are rebuilt on every scrape.
+func (t *GaugeVec) Reset() {
+	t.family.mutex.Lock()
+	t.family.children = make(map[string]*metricChild)
+	t.family.mutex.Unlock()
+}
+
+type HistogramVec struct {
+	family *metricFamily
+}
+
+func (t *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	return t.family.getChild(labelValues).histogram
+}
+
+func (t *HistogramVec) DeleteLabelValues(labelValues ...string) {
+	t.family.deleteChild(labelValues)
+}
+
+// MetricsRegistry holds metric families and writes them in Prometheus text format.
+// Collectors run before every scrape, so that gauges derived from the state of the application are up to date.
+type MetricsRegistry struct {
+	mutex      sync.RWMutex
+	familyMap  map[string]*metricFamily
+	collectors []func()
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		familyMap: make(map[string]*metricFamily),
+	}
+}
+
+func (t *MetricsRegistry) register(family *metricFamily) error {
+	if !metricNameRegexp.MatchString(family.name) {
+		return fmt.Errorf("invalid metric name %v", family.name)
+	}
+	for _, labelName := range family.labelNames {
+		if !metricLabelRegexp.MatchString(labelName) || labelName == "le" {
+			return fmt.Errorf("invalid label name %v of metric %v", labelName, family.name)
+		}
+	}
+	family.children = make(map[string]*metricChild)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, exist := t.familyMap[family.name]; exist {
+		return fmt.Errorf("metric %v has already been registered", family.name)
+	}
+	t.familyMap[family.name] = family
+	return nil
+}
+
+func (t *MetricsRegistry) RegisterCounterVec(name, help string, labelNames ...string) (*CounterVec, error) {
+	family := &metricFamily{name: name, help: help, tpy: MetricTypeCounter, labelNames: labelNames}
+	if err := t.register(family); err != nil {
+		return nil, err
+	}
+	return &CounterVec{family: family}, nil
+}
+
+func (t *MetricsRegistry) RegisterGaugeVec(name, help string, labelNames ...string) (*GaugeVec, error) {
+	family := &metricFamily{name: name, help: help, tpy: MetricTypeGauge, labelNames: labelNames}
+	if err := t.register(family); err != nil {
+		return nil, err
+	}
+	return &GaugeVec{family: family}, nil
+}
+
+// RegisterHistogramVec registers a histogram, DefaultHistogramBuckets is used if buckets is empty.
+func (t *MetricsRegistry) RegisterHistogramVec(name, help string, buckets []float64, labelNames ...string) (*HistogramVec, error) {
+	if len(buckets) <= 0 {
+		buckets = DefaultHistogramBuckets
+	}
+	upperBounds := append([]float64{}, buckets...)
+	sort.Float64s(upperBounds)
+
+	family := &metricFamily{name: name, help: help, tpy: MetricTypeHistogram, labelNames: labelNames, upperBounds: upperBounds}
+	if err := t.register(family); err != nil {
+		return nil, err
+	}
+	return &HistogramVec{family: family}, nil
+}
+
+func (t *MetricsRegistry) RegisterCounter(name, help string) (*Counter, error) {
+	vec, err := t.RegisterCounterVec(name, help)
+	if err != nil {
+		return nil, err
+	}
+	return vec.WithLabelValues(), nil
+}
+
+func (t *MetricsRegistry) RegisterGauge(name, help string) (*Gauge, error) {
+	vec, err := t.RegisterGaugeVec(name, help)
+	if err != nil {
+		return nil, err
+	}
+	return vec.WithLabelValues(), nil
+}
+
+func (t *MetricsRegistry) RegisterHistogram(name, help string, buckets []float64) (*Histogram, error) {
+	vec, err := t.RegisterHistogramVec(name, help, buckets)
+	if err != nil {
+		return nil, err
+	}
+	return vec.WithLabelValues(), nil
+}
+
+// RegisterGaugeFunc registers a gauge whose value is read from f on every scrape.
+func (t *MetricsRegistry) RegisterGaugeFunc(name, help string, f func() float64) error {
+	return t.register(&metricFamily{name: name, help: help, tpy: MetricTypeGauge, gaugeFunc: f})
+}
+
+// RegisterCollector registers a function that runs before every scrape.
+func (t *MetricsRegistry) RegisterCollector(f func()) {
+	t.mutex.Lock()
+	t.collectors = append(t.collectors, f)
+	t.mutex.Unlock()
+}
+
+// WriteText runs the collectors and writes all metrics in Prometheus text exposition format.
+func (t *MetricsRegistry) WriteText(w io.Writer) error {
+	t.mutex.RLock()
+	collectors := append([]func(){}, t.collectors...)
+	t.mutex.RUnlock()
+	for _, f := range collectors {
+		f()
+	}
+
+	t.mutex.RLock()
+	families := make([]*metricFamily, 0, len(t.familyMap))
+	for _, family := range t.familyMap {
+		families = append(families, family)
+	}
+	t.mutex.RUnlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	var sb strings.Builder
+	for _, family := range families {
+		writeMetricFamily(&sb, family)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMetricFamily(sb *strings.Builder, family *metricFamily) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", family.name, escapeMetricHelp(family.help))
+	fmt.Fprintf(sb, "# TYPE %s %s\n", family.name, family.tpy)
+
+	if family.gaugeFunc != nil {
+		writeMetricSample(sb, family.name, nil, nil, family.gaugeFunc())
+		return
+	}
+
+	family.mutex.RLock()
+	children := make([]*metricChild, 0, len(family.children))
+	for _, child := range family.children {
+		children = append(children, child)
+	}
+	family.mutex.RUnlock()
+	sort.Slice(children, func(i, j int) bool {
+		return strings.Join(children[i].labelValues, "\xff") < strings.Join(children[j].labelValues, "\xff")
+	})
+
+	for _, child := range children {
+		switch family.tpy {
+		case MetricTypeCounter:
+			writeMetricSample(sb, family.name, family.labelNames, child.labelValues, child.counter.Value())
+		case MetricTypeGauge:
+			writeMetricSample(sb, family.name, family.labelNames, child.labelValues, child.gauge.Value())
+		case MetricTypeHistogram:
+			labelNames := append(append([]string{}, family.labelNames...), "le")
+			var cumulative uint64
+			for idx, upperBound := range family.upperBounds {
+				cumulative += child.histogram.counts[idx].Load()
+				writeMetricSample(sb, family.name+"_bucket", labelNames,
+					append(append([]string{}, child.labelValues...), formatMetricValue(upperBound)), float64(cumulative))
+			}
+			count := child.histogram.count.Load()
+			writeMetricSample(sb, family.name+"_bucket", labelNames,
+				append(append([]string{}, child.labelValues...), "+Inf"), float64(count))
+			writeMetricSample(sb, family.name+"_sum", family.labelNames, child.labelValues, child.histogram.sum.Load())
+			writeMetricSample(sb, family.name+"_count", family.labelNames, child.labelValues, float64(count))
+		}
+	}
+}
+
+func writeMetricSample(sb *strings.Builder, name string, labelNames, labelValues []string, val float64) {
+	sb.WriteString(name)
+	if len(labelNames) > 0 {
+		sb.WriteByte('{')
+		for idx, labelName := range labelNames {
+			if idx > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(sb, "%s=\"%s\"", labelName, escapeMetricLabelValue(labelValues[idx]))
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatMetricValue(val))
+	sb.WriteByte('\n')
+}
+
+func formatMetricValue(val float64) string {
+	switch {
+	case math.IsInf(val, 1):
+		return "+Inf"
+	case math.IsInf(val, -1):
+		return "-Inf"
+	case math.IsNaN(val):
+		return "NaN"
+	}
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+func escapeMetricHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeMetricLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+type metricsServer struct {
+	server *http.Server
+}
+
+func (t *metricsServer) start(cfg MetricsConfig) error {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultMetricsAddr
+	}
+	metricsPath := cfg.Path
+	if metricsPath == "" {
+		metricsPath = defaultMetricsPath
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+metricsPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		if err := GetMetricsRegistry().WriteText(w); err != nil {
+			getLoggerInst().WarningF("Failed to write metrics, %v", err)
+		}
+	})
+
+	listener, listenErr := net.Listen("tcp", addr)
+	if listenErr != nil {
+		return listenErr
+	}
+
+	t.server = &http.Server{Handler: mux, ReadHeaderTimeout: adminReadHeaderTimeout}
+	go func() {
+		if err := t.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			getLoggerInst().WarningF("The metrics server has quit, %v", err)
+		}
+	}()
+
+	getLoggerInst().InfoF("The metrics server is listening on %v%v", listener.Addr(), metricsPath)
+	return nil
+}
+
+func (t *metricsServer) stop(ctx context.Context) error {
+	if t.server == nil {
+		return nil
+	}
+	return t.server.Shutdown(ctx)
+}
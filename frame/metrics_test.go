@@ -0,0 +1,166 @@
+package frame
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMetricsRegistryWriteText(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, registry *MetricsRegistry)
+		want  string
+	}{
+		{
+			name: "counter",
+			setup: func(t *testing.T, registry *MetricsRegistry) {
+				counter, err := registry.RegisterCounter("test_total", "The total.")
+				if err != nil {
+					t.Fatal(err)
+				}
+				counter.Add(2.5)
+				counter.Inc()
+			},
+			want: "# HELP test_total The total.\n" +
+				"# TYPE test_total counter\n" +
+				"test_total 3.5\n",
+		},
+		{
+			name: "labels sorted by value and escaped",
+			setup: func(t *testing.T, registry *MetricsRegistry) {
+				vec, err := registry.RegisterGaugeVec("test_gauge", "Line one\nback\\slash", "name", "mode")
+				if err != nil {
+					t.Fatal(err)
+				}
+				vec.WithLabelValues("b", "x").Set(-1)
+				vec.WithLabelValues("a", "say \"hi\"\n").Set(1e21)
+				vec.WithLabelValues("c", "gone").Set(1)
+				vec.DeleteLabelValues("c", "gone")
+			},
+			want: "# HELP test_gauge Line one\\nback\\\\slash\n" +
+				"# TYPE test_gauge gauge\n" +
+				"test_gauge{name=\"a\",mode=\"say \\\"hi\\\"\\n\"} 1e+21\n" +
+				"test_gauge{name=\"b\",mode=\"x\"} -1\n",
+		},
+		{
+			name: "histogram buckets are cumulative",
+			setup: func(t *testing.T, registry *MetricsRegistry) {
+				vec, err := registry.RegisterHistogramVec("test_seconds", "The duration.", []float64{0.1, 1}, "op")
+				if err != nil {
+					t.Fatal(err)
+				}
+				histogram := vec.WithLabelValues("read")
+				histogram.Observe(0.05)
+				histogram.Observe(0.1)
+				histogram.Observe(0.5)
+				histogram.Observe(3)
+			},
+			want: "# HELP test_seconds The duration.\n" +
+				"# TYPE test_seconds histogram\n" +
+				"test_seconds_bucket{op=\"read\",le=\"0.1\"} 2\n" +
+				"test_seconds_bucket{op=\"read\",le=\"1\"} 3\n" +
+				"test_seconds_bucket{op=\"read\",le=\"+Inf\"} 4\n" +
+				"test_seconds_sum{op=\"read\"} 3.65\n" +
+				"test_seconds_count{op=\"read\"} 4\n",
+		},
+		{
+			name: "gauge func, special values and family order",
+			setup: func(t *testing.T, registry *MetricsRegistry) {
+				if err := registry.RegisterGaugeFunc("test_b", "B.", func() float64 { return math.Inf(1) }); err != nil {
+					t.Fatal(err)
+				}
+				gauge, err := registry.RegisterGauge("test_a", "A.")
+				if err != nil {
+					t.Fatal(err)
+				}
+				gauge.Set(math.NaN())
+			},
+			want: "# HELP test_a A.\n" +
+				"# TYPE test_a gauge\n" +
+				"test_a NaN\n" +
+				"# HELP test_b B.\n" +
+				"# TYPE test_b gauge\n" +
+				"test_b +Inf\n",
+		},
+		{
+			name: "collectors run before writing",
+			setup: func(t *testing.T, registry *MetricsRegistry) {
+				gauge, err := registry.RegisterGauge("test_collected", "Collected.")
+				if err != nil {
+					t.Fatal(err)
+				}
+				registry.RegisterCollector(func() {
+					gauge.Inc()
+				})
+			},
+			want: "# HELP test_collected Collected.\n" +
+				"# TYPE test_collected gauge\n" +
+				"test_collected 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewMetricsRegistry()
+			tt.setup(t, registry)
+
+			var sb strings.Builder
+			if err := registry.WriteText(&sb); err != nil {
+				t.Fatal(err)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsRegistryRejectsInvalidRegistrations(t *testing.T) {
+	registry := NewMetricsRegistry()
+	if _, err := registry.RegisterCounter("test_total", "The total."); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		register func() error
+	}{
+		{
+			name: "duplicate name",
+			register: func() error {
+				_, err := registry.RegisterGauge("test_total", "The total.")
+				return err
+			},
+		},
+		{
+			name: "invalid name",
+			register: func() error {
+				_, err := registry.RegisterCounter("0test", "Invalid.")
+				return err
+			},
+		},
+		{
+			name: "invalid label name",
+			register: func() error {
+				_, err := registry.RegisterCounterVec("test_labels", "Invalid.", "bad-label")
+				return err
+			},
+		},
+		{
+			name: "reserved label name",
+			register: func() error {
+				_, err := registry.RegisterHistogramVec("test_le", "Invalid.", []float64{1}, "le")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.register(); err == nil {
+				t.Fatal("the registration succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultProbeAddr               = "0.0.0.0:8086"
+	defaultLivenessTimeoutSec      = 10
+	livenessHeartbeatIntervalMilli = 1000
+)
+
+// ProbeConfig configures the listener of the Kubernetes-style probe endpoints /livez, /readyz and /startupz.
+// The liveness probe fails when the heartbeat of the frame has not been updated within LivenessTimeoutSec, and once
+// the application has started, when a lifecycle operation of the components has run LivenessTimeoutSec past its
+// deadline, a load of a configuration has been running longer than LivenessTimeoutSec, or the loop of the health
+// checks has stalled for LivenessTimeoutSec. The deadline of an operation is the deadline of its context, such as
+// the timeout of a restart by the admin server, or else the timeout of the component being started or stopped.
+type ProbeConfig struct {
+	Enable             bool   `json:"enable"`
+	Addr               string `json:"addr"`
+	LivenessTimeoutSec uint64 `json:"liveness_timeout_sec"`
+}
+
+var (
+	probeServerInst = &probeServer{}
+)
+
+type probeServer struct {
+	server          *http.Server
+	livenessTimeout time.Duration
+	heartbeat       atomic.Int64
+	cancel          context.CancelFunc
+}
+
+func (t *probeServer) start(cfg ProbeConfig) error {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = defaultProbeAddr
+	}
+	t.livenessTimeout = time.Duration(cfg.LivenessTimeoutSec) * time.Second
+	if t.livenessTimeout <= 0 {
+		t.livenessTimeout = time.Second * defaultLivenessTimeoutSec
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /livez", t.handleLivez)
+	mux.HandleFunc("GET /readyz", t.handleReadyz)
+	mux.HandleFunc("GET /startupz", t.handleStartupz)
+
+	listener, listenErr := net.Listen("tcp", addr)
+	if listenErr != nil {
+		return listenErr
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.heartbeat.Store(time.Now().UnixNano())
+	go t.loopHeartbeat(ctx)
+
+	t.server = &http.Server{Handler: mux, ReadHeaderTimeout: adminReadHeaderTimeout}
+	go func() {
+		if err := t.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			getLoggerInst().WarningF("The probe server has quit, %v", err)
+		}
+	}()
+
+	getLoggerInst().InfoF("The probe server is listening on %v", listener.Addr())
+	return nil
+}
+
+func (t *probeServer) stop(ctx context.Context) error {
+	if t.cancel != nil {
+		t.cancel()
+	}
+	if t.server == nil {
+		return nil
+	}
+	return t.server.Shutdown(ctx)
+}
+
+// loopHeartbeat updates the heartbeat as long as the scheduler runs the goroutines of the frame, a stuck operation
+// of the components is detected by its deadline instead.
+func (t *probeServer) loopHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(time.Millisecond * livenessHeartbeatIntervalMilli)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.heartbeat.Store(time.Now().UnixNano())
+		}
+	}
+}
+
+func (t *probeServer) handleLivez(w http.ResponseWriter, r *http.Request) {
+	reasons := t.getNotLiveReasons()
+	if len(reasons) > 0 {
+		writeAdminJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "fail", "reasons": reasons})
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// getNotLiveReasons returns why the frame is wedged, it is live if nothing is returned. The progress of the frame
+// loops is checked only between the startup and the shutdown, which are bounded by the startup probe and
+// the shutdown timeout.
+func (t *probeServer) getNotLiveReasons() (retReasons []string) {
+	if sinceHeartbeat := time.Since(time.Unix(0, t.heartbeat.Load())); sinceHeartbeat > t.livenessTimeout {
+		retReasons = append(retReasons, fmt.Sprintf("the frame heartbeat has not been updated for %v",
+			sinceHeartbeat.Truncate(time.Millisecond)))
+	}
+	if !appStartupDone.Load() || appStopping.Load() {
+		return
+	}
+
+	// An operation is measured against its own deadline, and LivenessTimeoutSec is the grace period after it
+	if overrun := GetComponentMgr().getOpOverrun(); overrun > t.livenessTimeout {
+		retReasons = append(retReasons, fmt.Sprintf("a lifecycle operation of the components has overrun its deadline by %v",
+			overrun.Truncate(time.Millisecond)))
+	}
+
+	loadDurations := GetConfigWatcherMgr().getLoadDurations()
+	keys := make([]string, 0, len(loadDurations))
+	for key := range loadDurations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if loadDurations[key] > t.livenessTimeout {
+			retReasons = append(retReasons, fmt.Sprintf("the configuration %v has been loading for %v",
+				key, loadDurations[key].Truncate(time.Millisecond)))
+		}
+	}
+
+	if stalled := GetHealthMgr().getStalledDuration(); stalled > t.livenessTimeout {
+		retReasons = append(retReasons, fmt.Sprintf("the loop of the health checks has stalled for %v",
+			stalled.Truncate(time.Millisecond)))
+	}
+
+	return
+}
+
+func (t *probeServer) handleStartupz(w http.ResponseWriter, r *http.Request) {
+	if !appStartupDone.Load() {
+		writeAdminJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "fail", "reason": "the application is starting"})
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (t *probeServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	reasons := getNotReadyReasons()
+	if len(reasons) > 0 {
+		writeAdminJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "fail", "reasons": reasons})
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// getNotReadyReasons returns why the application cannot serve traffic, it is ready if nothing is returned.
+// The application is ready once it has started, until shutdown begins, as long as no component is unhealthy
+// and all configurations that must be loaded have been loaded.
+func getNotReadyReasons() (retReasons []string) {
+	if appStopping.Load() {
+		return []string{"the application is stopping"}
+	}
+	if !appStarted.Load() {
+		return []string{"the application is starting"}
+	}
+
+	for _, info := range GetHealthMgr().GetAppHealthInfo().Components {
+		if info.State == HealthStateUnhealthy {
+			retReasons = append(retReasons, fmt.Sprintf("the component %v is unhealthy, %v", info.ID, info.Message))
+		}
+	}
+
+	keys := GetConfigWatcherMgr().getUnloadedMustLoadKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		retReasons = append(retReasons, fmt.Sprintf("the configuration %v has not been loaded", key))
+	}
+
+	return
+}
+
+// IsAppReady reports whether the application is ready to serve traffic.
+func IsAppReady() bool {
+	return len(getNotReadyReasons()) == 0
+}
@@ -0,0 +1,121 @@
+package frame
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func checkNotLiveReason(t *testing.T, probe *probeServer, want string) {
+	t.Helper()
+	reasons := probe.getNotLiveReasons()
+	if want == "" {
+		if len(reasons) > 0 {
+			t.Fatalf("got reasons %v, want live", reasons)
+		}
+		return
+	}
+	if len(reasons) != 1 || !strings.Contains(reasons[0], want) {
+		t.Fatalf("got reasons %v, want %q", reasons, want)
+	}
+}
+
+func TestLivenessDetectsStalledFrame(t *testing.T) {
+	appStartupDone.Store(true)
+	defer appStartupDone.Store(false)
+
+	stale := time.Now().Add(-time.Minute).UnixNano()
+	probe := &probeServer{livenessTimeout: time.Second}
+	probe.heartbeat.Store(time.Now().UnixNano())
+	checkNotLiveReason(t, probe, "")
+
+	probe.heartbeat.Store(stale)
+	checkNotLiveReason(t, probe, "the frame heartbeat has not been updated")
+	probe.heartbeat.Store(time.Now().UnixNano())
+
+	// An operation is judged by its own deadline, which may be far beyond the liveness timeout
+	mgr := GetComponentMgr()
+	mgr.lockOp(context.Background())
+	mgr.opBegin.Store(stale)
+	checkNotLiveReason(t, probe, "a lifecycle operation of the components has overrun its deadline")
+	mgr.beginOpStep(context.Background(), time.Minute)
+	mgr.opBegin.Store(stale)
+	checkNotLiveReason(t, probe, "")
+	mgr.opDeadline.Store(stale)
+	checkNotLiveReason(t, probe, "a lifecycle operation of the components has overrun its deadline")
+	mgr.unlockOp()
+	checkNotLiveReason(t, probe, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*adminRestartTimeoutSec)
+	defer cancel()
+	mgr.lockOp(ctx)
+	mgr.opBegin.Store(stale)
+	mgr.beginOpStep(ctx, 0)
+	checkNotLiveReason(t, probe, "")
+	mgr.unlockOp()
+
+	configMgr := &ConfigWatcherMgr{watcherMap: map[string]*ConfigWatcher{"test_probe": {key: "test_probe"}}}
+	prevConfigMgr := configWatcherMgr
+	configWatcherMgr = configMgr
+	configMgr.watcherMap["test_probe"].loadBegin.Store(stale)
+	checkNotLiveReason(t, probe, "the configuration test_probe has been loading")
+	configMgr.watcherMap["test_probe"].loadBegin.Store(0)
+	checkNotLiveReason(t, probe, "")
+	configWatcherMgr = prevConfigMgr
+
+	healthMgr := GetHealthMgr()
+	prevInterval, prevTimeout := healthMgr.interval, healthMgr.timeout
+	healthMgr.interval, healthMgr.timeout = time.Second, time.Second
+	healthMgr.progress.Store(stale)
+	checkNotLiveReason(t, probe, "the loop of the health checks has stalled")
+	healthMgr.interval, healthMgr.timeout = prevInterval, prevTimeout
+	healthMgr.progress.Store(0)
+
+	// The frame loops are not checked during the shutdown, which has its own timeout
+	appStopping.Store(true)
+	defer appStopping.Store(false)
+	mgr.lockOp(context.Background())
+	mgr.opBegin.Store(stale)
+	checkNotLiveReason(t, probe, "")
+	mgr.unlockOp()
+}
+
+func TestReadinessFollowsTheApplicationLifecycle(t *testing.T) {
+	probe := &probeServer{}
+	readyz := http.HandlerFunc(probe.handleReadyz)
+	startupz := http.HandlerFunc(probe.handleStartupz)
+
+	if code := serveAdminRequest(startupz, http.MethodGet, "/startupz", "", nil).Code; code != http.StatusServiceUnavailable {
+		t.Errorf("got startup code %d before the startup, want 503", code)
+	}
+	if reasons := getNotReadyReasons(); len(reasons) != 1 || reasons[0] != "the application is starting" {
+		t.Fatalf("got reasons %v before the startup", reasons)
+	}
+
+	appStartupDone.Store(true)
+	appStarted.Store(true)
+	defer func() {
+		appStartupDone.Store(false)
+		appStarted.Store(false)
+		appStopping.Store(false)
+	}()
+	useTestComponentMgr(t, &ComponentMgr{})
+	if code := serveAdminRequest(startupz, http.MethodGet, "/startupz", "", nil).Code; code != http.StatusOK {
+		t.Errorf("got startup code %d after the startup, want 200", code)
+	}
+	if recorder := serveAdminRequest(readyz, http.MethodGet, "/readyz", "", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("got ready code %d, %s", recorder.Code, recorder.Body)
+	}
+
+	// Readiness turns false as soon as the shutdown begins, while the startup stays done
+	appStopping.Store(true)
+	recorder := serveAdminRequest(readyz, http.MethodGet, "/readyz", "", nil)
+	if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "the application is stopping") {
+		t.Fatalf("got ready code %d, %s during the shutdown", recorder.Code, recorder.Body)
+	}
+	if code := serveAdminRequest(startupz, http.MethodGet, "/startupz", "", nil).Code; code != http.StatusOK {
+		t.Errorf("got startup code %d during the shutdown, want 200", code)
+	}
+}
@@ -18,8 +18,13 @@ const (
 
 var (
 	currentProcessType = MainProcessType
+	currentAppID       string
 )
 
+func GetCurrentAppID() string {
+	return currentAppID
+}
+
 func GetCurrentProcessType() ProcessType {
 	return currentProcessType
 }
@@ -46,12 +51,23 @@ func startSubprocess(execPath string, kwArgs map[string]interface{}) (*exec.Cmd,
 
 	argsStr := fmt.Sprintf("%s %s", execPath, strings.Join(arg, " "))
 	if err := cmd.Start(); err != nil {
+		subProcessStartCounter.WithLabelValues("failure").Inc()
 		return nil, argsStr, err
 	}
 
+	subProcessStartCounter.WithLabelValues("success").Inc()
+	publishFrameEvent(EventSubProcessStarted, SubProcessStartedEvent{Pid: cmd.Process.Pid, Args: argsStr})
 	return cmd, argsStr, nil
 }
 
+// waitSubprocess waits for the sub process to exit and reports the exit.
+func waitSubprocess(cmd *exec.Cmd) {
+	waitErr := cmd.Wait()
+	subProcessExitCounter.Inc()
+	getLoggerInst().WarningF("The sub process %d has exited, %v", cmd.Process.Pid, waitErr)
+	publishFrameEvent(EventSubProcessExited, SubProcessExitedEvent{Pid: cmd.Process.Pid, Err: waitErr})
+}
+
 func updateProcessIdFile(pidFilePath string) (int, error) {
 	f, openPidFileErr := os.OpenFile(pidFilePath, os.O_CREATE|os.O_RDWR, os.ModePerm)
 	if openPidFileErr != nil {
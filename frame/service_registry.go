@@ -0,0 +1,203 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type ServiceID string
+
+var (
+	serviceRegistryInst = &serviceRegistry{
+		serviceMap:     make(map[ServiceID]*serviceEntry),
+		requirementMap: make(map[ComponentID][]serviceRequirement),
+	}
+	ErrServiceNotFound     = errors.New("service not found")
+	ErrServiceTypeMismatch = errors.New("service type mismatch")
+)
+
+type serviceEntry struct {
+	owner   ComponentID
+	service interface{}
+}
+
+type serviceRequirement struct {
+	id  ServiceID
+	tpy reflect.Type
+}
+
+// serviceRegistry holds the services published by components, so that components can find each other
+// without package globals.
+type serviceRegistry struct {
+	mutex          sync.RWMutex
+	serviceMap     map[ServiceID]*serviceEntry
+	requirementMap map[ComponentID][]serviceRequirement
+}
+
+// PublishService publishes a named service owned by the component, usually during Initialize.
+// A component may publish the same id again to replace its own service, but not a service owned by another component.
+func PublishService(owner IComponent, id ServiceID, service interface{}) error {
+	if service == nil {
+		return fmt.Errorf("the service %v is a nil value", id)
+	}
+
+	serviceRegistryInst.mutex.Lock()
+	defer serviceRegistryInst.mutex.Unlock()
+
+	if entry, exist := serviceRegistryInst.serviceMap[id]; exist && entry.owner != owner.GetID() {
+		return fmt.Errorf("the service %v has already been published by component %v", id, entry.owner)
+	}
+	serviceRegistryInst.serviceMap[id] = &serviceEntry{owner: owner.GetID(), service: service}
+	return nil
+}
+
+// RequireService declares that the component requires the service of type T. The requirements are checked
+// after the component has been initialized, so that a missing provider fails the startup before anything starts.
+// The provider of the service becomes a dependency of the component, so it must be ordered before the component
+// through 'depends_on' or its priority, otherwise the startup fails.
+func RequireService[T any](consumer IComponent, id ServiceID) {
+	serviceRegistryInst.mutex.Lock()
+	defer serviceRegistryInst.mutex.Unlock()
+
+	consumerID := consumer.GetID()
+	serviceRegistryInst.requirementMap[consumerID] = append(serviceRegistryInst.requirementMap[consumerID],
+		serviceRequirement{id: id, tpy: reflect.TypeOf((*T)(nil)).Elem()})
+}
+
+// Resolve returns the service published under the id as type T.
+func Resolve[T any](id ServiceID) (T, error) {
+	var zero T
+
+	serviceRegistryInst.mutex.RLock()
+	entry, exist := serviceRegistryInst.serviceMap[id]
+	serviceRegistryInst.mutex.RUnlock()
+	if !exist {
+		return zero, fmt.Errorf("%w, ServiceID: %v", ErrServiceNotFound, id)
+	}
+
+	service, ok := entry.service.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w, ServiceID: %v, Expected: %v, Actual: %T",
+			ErrServiceTypeMismatch, id, reflect.TypeOf((*T)(nil)).Elem(), entry.service)
+	}
+	return service, nil
+}
+
+// MustResolve is like Resolve but panics if the service cannot be resolved.
+func MustResolve[T any](id ServiceID) T {
+	service, err := Resolve[T](id)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+// GetServiceIDList returns the ids of all published services.
+func GetServiceIDList() []ServiceID {
+	serviceRegistryInst.mutex.RLock()
+	defer serviceRegistryInst.mutex.RUnlock()
+
+	retList := make([]ServiceID, 0, len(serviceRegistryInst.serviceMap))
+	for id := range serviceRegistryInst.serviceMap {
+		retList = append(retList, id)
+	}
+	sort.Slice(retList, func(i, j int) bool {
+		return retList[i] < retList[j]
+	})
+	return retList
+}
+
+// checkServiceRequirements checks that every service required by the components has been published
+// with a compatible type.
+func checkServiceRequirements(consumerIDs ...ComponentID) error {
+	serviceRegistryInst.mutex.RLock()
+	defer serviceRegistryInst.mutex.RUnlock()
+
+	var errs []error
+	for _, consumerID := range consumerIDs {
+		for _, req := range serviceRegistryInst.requirementMap[consumerID] {
+			entry, exist := serviceRegistryInst.serviceMap[req.id]
+			if !exist {
+				errs = append(errs, fmt.Errorf("component %v requires service %v, %w", consumerID, req.id, ErrServiceNotFound))
+				continue
+			}
+			if !reflect.TypeOf(entry.service).AssignableTo(req.tpy) {
+				errs = append(errs, fmt.Errorf("component %v requires service %v of type %v, but component %v published %T, %w",
+					consumerID, req.id, req.tpy, entry.owner, entry.service, ErrServiceTypeMismatch))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serviceProvider is the component that publishes a service required by another component.
+type serviceProvider struct {
+	service ServiceID
+	owner   ComponentID
+}
+
+// getServiceProviders returns the providers of the services required by the consumer in the order of the requirements,
+// the services that have not been published are skipped.
+func getServiceProviders(consumerID ComponentID) (retProviders []serviceProvider) {
+	serviceRegistryInst.mutex.RLock()
+	defer serviceRegistryInst.mutex.RUnlock()
+
+	for _, req := range serviceRegistryInst.requirementMap[consumerID] {
+		if entry, exist := serviceRegistryInst.serviceMap[req.id]; exist && entry.owner != consumerID {
+			retProviders = append(retProviders, serviceProvider{service: req.id, owner: entry.owner})
+		}
+	}
+	return
+}
+
+// removeComponentServices removes the services published and the requirements declared by the component.
+func removeComponentServices(owner ComponentID) {
+	serviceRegistryInst.mutex.Lock()
+	defer serviceRegistryInst.mutex.Unlock()
+
+	for id, entry := range serviceRegistryInst.serviceMap {
+		if entry.owner == owner {
+			delete(serviceRegistryInst.serviceMap, id)
+		}
+	}
+	delete(serviceRegistryInst.requirementMap, owner)
+}
+
+// componentServices is a copy of the services published and the requirements declared by a component.
+type componentServices struct {
+	services     map[ServiceID]*serviceEntry
+	requirements []serviceRequirement
+}
+
+func getComponentServices(owner ComponentID) componentServices {
+	serviceRegistryInst.mutex.RLock()
+	defer serviceRegistryInst.mutex.RUnlock()
+
+	ret := componentServices{services: make(map[ServiceID]*serviceEntry)}
+	for id, entry := range serviceRegistryInst.serviceMap {
+		if entry.owner == owner {
+			ret.services[id] = entry
+		}
+	}
+	ret.requirements = append(ret.requirements, serviceRegistryInst.requirementMap[owner]...)
+	return ret
+}
+
+// restoreComponentServices replaces the services and the requirements of the component with the copy.
+func restoreComponentServices(owner ComponentID, copied componentServices) {
+	removeComponentServices(owner)
+
+	serviceRegistryInst.mutex.Lock()
+	defer serviceRegistryInst.mutex.Unlock()
+
+	for id, entry := range copied.services {
+		serviceRegistryInst.serviceMap[id] = entry
+	}
+	if len(copied.requirements) > 0 {
+		serviceRegistryInst.requirementMap[owner] = copied.requirements
+	}
+}
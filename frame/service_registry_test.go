@@ -0,0 +1,242 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const (
+	testProviderType ComponentType = "TestServiceProvider"
+	testConsumerType ComponentType = "TestServiceConsumer"
+	// testUndeclaredType requires the service of the provider without declaring the dependency
+	testUndeclaredType ComponentType = "TestServiceUndeclared"
+	testOrphanType     ComponentType = "TestServiceOrphan"
+	testProviderSvcID  ServiceID     = "TestServiceProvider"
+)
+
+type testProviderKW struct {
+	Port int `json:"port"`
+}
+
+type testProviderComponent struct {
+	BaseComponent
+	port int
+}
+
+func (t *testProviderComponent) Initialize(kw IComponentKW) error {
+	t.port = kw.(*testProviderKW).Port
+	if t.port < 0 {
+		return fmt.Errorf("invalid port %d", t.port)
+	}
+	return PublishService(t, testProviderSvcID, t)
+}
+
+type testConsumerComponent struct {
+	BaseComponent
+	provider *testProviderComponent
+}
+
+func (t *testConsumerComponent) Initialize(kw IComponentKW) error {
+	RequireService[*testProviderComponent](t, testProviderSvcID)
+	return nil
+}
+
+func (t *testConsumerComponent) Start() error {
+	provider, err := Resolve[*testProviderComponent](testProviderSvcID)
+	t.provider = provider
+	return err
+}
+
+type testOrphanComponent struct {
+	BaseComponent
+}
+
+func (t *testOrphanComponent) Initialize(kw IComponentKW) error {
+	RequireService[string](t, "TestServiceAbsent")
+	return nil
+}
+
+func init() {
+	RegisterComponentInfo(ComponentPriorityGeneral, testOrphanType, func() IComponent { return &testOrphanComponent{} },
+		func() IComponentKW { return nil })
+	RegisterComponentInfo(ComponentPriorityGeneral, testProviderType, func() IComponent { return &testProviderComponent{} },
+		func() IComponentKW { return &testProviderKW{} })
+	RegisterComponentInfo(ComponentPriorityGeneral, testConsumerType, func() IComponent { return &testConsumerComponent{} },
+		func() IComponentKW { return nil }, string(testProviderType))
+	RegisterComponentInfo(ComponentPriorityGeneral, testUndeclaredType, func() IComponent { return &testConsumerComponent{} },
+		func() IComponentKW { return nil })
+}
+
+func newTestServiceOwner(t *testing.T, index int) IComponent {
+	t.Helper()
+	owner := &testMgrComponent{}
+	if err := owner.baseInitialize(index, "TestServiceOwner", genComponentID("TestServiceOwner", index)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		removeComponentServices(owner.GetID())
+	})
+	return owner
+}
+
+func TestServiceRegistryResolve(t *testing.T) {
+	owner, other := newTestServiceOwner(t, 0), newTestServiceOwner(t, 1)
+
+	if err := PublishService(owner, "TestResolve", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PublishService(owner, "TestResolve", "v2"); err != nil {
+		t.Fatalf("the owner cannot replace its service, %v", err)
+	}
+	if err := PublishService(other, "TestResolve", "v3"); err == nil || !strings.Contains(err.Error(), "TestServiceOwner_0") {
+		t.Fatalf("got error %v, want a conflict with the owner", err)
+	}
+	if err := PublishService(owner, "TestResolveNil", nil); err == nil {
+		t.Fatal("a nil service has been published")
+	}
+
+	if service, err := Resolve[string]("TestResolve"); err != nil || service != "v2" {
+		t.Fatalf("got %q, %v, want v2", service, err)
+	}
+	if _, err := Resolve[int]("TestResolve"); !errors.Is(err, ErrServiceTypeMismatch) {
+		t.Fatalf("got error %v, want ErrServiceTypeMismatch", err)
+	}
+	if _, err := Resolve[string]("TestResolveAbsent"); !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("got error %v, want ErrServiceNotFound", err)
+	}
+
+	// Removing the component withdraws its services
+	removeComponentServices(owner.GetID())
+	if _, err := Resolve[string]("TestResolve"); !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("got error %v after the owner has been removed", err)
+	}
+}
+
+func TestCheckServiceRequirements(t *testing.T) {
+	owner, consumer := newTestServiceOwner(t, 2), newTestServiceOwner(t, 3)
+	RequireService[fmt.Stringer](consumer, "TestRequireStringer")
+	RequireService[string](consumer, "TestRequireString")
+
+	err := checkServiceRequirements(consumer.GetID())
+	if !errors.Is(err, ErrServiceNotFound) {
+		t.Fatalf("got error %v, want ErrServiceNotFound", err)
+	}
+
+	// An interface requirement accepts any implementation
+	if err := PublishService(owner, "TestRequireStringer", ComponentRunningStatus); err != nil {
+		t.Fatal(err)
+	}
+	if err := PublishService(owner, "TestRequireString", 1); err != nil {
+		t.Fatal(err)
+	}
+	err = checkServiceRequirements(consumer.GetID())
+	if errors.Is(err, ErrServiceNotFound) || !errors.Is(err, ErrServiceTypeMismatch) {
+		t.Fatalf("got error %v, want only ErrServiceTypeMismatch", err)
+	}
+}
+
+func TestComponentMgrFailsOnMissingService(t *testing.T) {
+	mgr := &ComponentMgr{}
+	err := mgr.initialize([]ComponentConfigModel{
+		{ComponentType: string(testMgrStoreType)},
+		{ComponentType: string(testOrphanType)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "requires service TestServiceAbsent") {
+		t.Fatalf("got error %v, want a missing service", err)
+	}
+	removeComponentServices("TestServiceOrphan_1")
+
+	// A component added at runtime is rejected and leaves no requirement behind
+	mgr = newTestComponentMgr(t, ComponentConfigModel{ComponentType: string(testMgrStoreType)})
+	if _, err := mgr.AddComponent(context.Background(), ComponentConfigModel{ComponentType: string(testOrphanType)}); err == nil {
+		t.Fatal("a component without its service has been added")
+	}
+	if got := len(mgr.GetComponentList()); got != 1 {
+		t.Fatalf("got %d components, want 1", got)
+	}
+	if err := checkServiceRequirements("TestServiceOrphan_1"); err != nil {
+		t.Fatalf("the requirement has been kept, %v", err)
+	}
+}
+
+func TestReconcileRestoresServicesOfFailedReplacement(t *testing.T) {
+	ctx := context.Background()
+	cfgList := func(port int) []ComponentConfigModel {
+		return []ComponentConfigModel{
+			{ComponentType: string(testProviderType), Kw: map[string]interface{}{"port": port}},
+			{ComponentType: string(testConsumerType)},
+		}
+	}
+	mgr := newTestComponentMgr(t, cfgList(1)...)
+	t.Cleanup(func() {
+		for _, component := range mgr.GetComponentList() {
+			removeComponentServices(component.GetID())
+		}
+	})
+	oldProvider, _ := mgr.GetComponentByID("TestServiceProvider_0")
+	consumer, _ := mgr.GetComponentByID("TestServiceConsumer_1")
+
+	// The new instance fails to initialize, the old one is restarted with its service
+	if err := mgr.reconcile(ctx, cfgList(-1)); err != nil {
+		t.Fatal(err)
+	}
+	if provider, err := Resolve[*testProviderComponent](testProviderSvcID); err != nil || provider != oldProvider {
+		t.Fatalf("got provider %v, %v, want the previous instance", provider, err)
+	}
+	for _, component := range []IComponent{oldProvider, consumer} {
+		if status := component.GetStatus(); status != ComponentRunningStatus {
+			t.Errorf("component %v is %v, want running", component.GetID(), status)
+		}
+	}
+
+	// A successful replacement restarts the consumer against the new provider
+	if err := mgr.reconcile(ctx, cfgList(2)); err != nil {
+		t.Fatal(err)
+	}
+	newProvider, _ := mgr.GetComponentByID("TestServiceProvider_0")
+	if newProvider == oldProvider {
+		t.Fatal("the provider has not been replaced")
+	}
+	if consumer.GetStatus() != ComponentRunningStatus || consumer.(*testConsumerComponent).provider != newProvider {
+		t.Error("the consumer has not been restarted against the new provider")
+	}
+}
+
+func TestServiceRequirementsAreDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	// The consumer would start before the provider of its service
+	mgr := &ComponentMgr{}
+	err := mgr.initialize([]ComponentConfigModel{
+		{ID: "consumer", ComponentType: string(testUndeclaredType)},
+		{ID: "provider", ComponentType: string(testProviderType)},
+	})
+	if err == nil || !strings.Contains(err.Error(), "component consumer requires service TestServiceProvider of component provider, which starts after it") {
+		t.Fatalf("got error %v, want the provider ordered after the consumer", err)
+	}
+	removeComponentServices("consumer")
+	removeComponentServices("provider")
+
+	mgr = newTestComponentMgr(t,
+		ComponentConfigModel{ID: "provider", ComponentType: string(testProviderType)},
+		ComponentConfigModel{ID: "consumer", ComponentType: string(testUndeclaredType)},
+	)
+	t.Cleanup(func() {
+		removeComponentServices("consumer")
+		removeComponentServices("provider")
+	})
+	if err := mgr.StopComponent(ctx, "provider"); err == nil || !strings.Contains(err.Error(), "[consumer]") {
+		t.Fatalf("got error %v, want the provider required by the running consumer", err)
+	}
+	if err := mgr.RestartComponent(ctx, "provider"); err != nil {
+		t.Fatal(err)
+	}
+	consumer, _ := mgr.GetComponentByID("consumer")
+	provider, _ := mgr.GetComponentByID("provider")
+	if consumer.GetStatus() != ComponentRunningStatus || consumer.(*testConsumerComponent).provider != provider {
+		t.Error("the consumer has not been restarted with its provider")
+	}
+}
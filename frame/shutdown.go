@@ -0,0 +1,94 @@
+package frame
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	forceExitCode = 1
+)
+
+// shutdownSignalHandler waits for the first termination signal to begin a graceful shutdown.
+// A second SIGINT or SIGTERM during the shutdown forces the process to exit immediately.
+type shutdownSignalHandler struct {
+	sigChan chan os.Signal
+}
+
+func newShutdownSignalHandler() *shutdownSignalHandler {
+	return &shutdownSignalHandler{
+		sigChan: make(chan os.Signal, defaultSignChanSize),
+	}
+}
+
+func (t *shutdownSignalHandler) waitSignal(pidFilePath string) os.Signal {
+	signal.Notify(t.sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	sig := <-t.sigChan
+	getLoggerInst().InfoF("Received signal %v, shutting down the application", sig)
+	publishFrameEvent(EventSignalReceived, SignalReceivedEvent{Signal: sig})
+
+	go func() {
+		for s := range t.sigChan {
+			if s != syscall.SIGINT && s != syscall.SIGTERM {
+				continue
+			}
+			getLoggerInst().WarningF("Received signal %v again during shutdown, forcing the application to exit", s)
+			publishFrameEvent(EventSignalReceived, SignalReceivedEvent{Signal: s})
+			if err := deleteProcessIdFile(pidFilePath); err != nil {
+				getLoggerInst().WarningF("Failed to delete the process id file, %v", err)
+			}
+			os.Exit(forceExitCode)
+		}
+	}()
+
+	return sig
+}
+
+func (t *shutdownSignalHandler) stop() {
+	signal.Stop(t.sigChan)
+}
+
+// shutdownApplication stops the application in phases. It publishes EventAPPStopping and marks the application
+// not ready, waits for the drain period so that load balancers stop sending traffic, stops the components within
+// the shutdown deadline, stops the configuration watchers and the servers of the frame, and publishes EventAPPStopped.
+func shutdownApplication(sig os.Signal, drainPeriod, shutdownTimeout time.Duration) {
+	begin := time.Now()
+	appStarted.Store(false)
+	appStopping.Store(true)
+	getLoggerInst().Info("Stopping the application")
+	publishFrameEvent(EventAPPStopping, APPStoppingEvent{AppID: currentAppID, Signal: sig, DrainPeriod: drainPeriod})
+
+	if drainPeriod > 0 {
+		getLoggerInst().InfoF("Draining traffic for %v before stopping components", drainPeriod)
+		time.Sleep(drainPeriod)
+	}
+
+	GetHealthMgr().stop()
+
+	// Stop all components in the reverse order of startup within the shutdown deadline
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	_ = GetComponentMgr().stopAll(shutdownCtx)
+	getLoggerInst().Info("Stopped all components")
+
+	if err := GetConfigWatcherMgr().stop(); err != nil {
+		getLoggerInst().WarningF("Failed to stop the configuration watcher manager, %v", err)
+	} else {
+		getLoggerInst().Info("Stopped the configuration watcher manager")
+	}
+
+	if err := adminServerInst.stop(shutdownCtx); err != nil {
+		getLoggerInst().WarningF("Failed to stop the admin server, %v", err)
+	}
+	if err := metricsServerInst.stop(shutdownCtx); err != nil {
+		getLoggerInst().WarningF("Failed to stop the metrics server, %v", err)
+	}
+	if err := probeServerInst.stop(shutdownCtx); err != nil {
+		getLoggerInst().WarningF("Failed to stop the probe server, %v", err)
+	}
+
+	publishFrameEvent(EventAPPStopped, APPStoppedEvent{AppID: currentAppID, ShutdownDuration: time.Since(begin)})
+}
@@ -0,0 +1,68 @@
+package frame
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShutdownApplicationInPhases(t *testing.T) {
+	mgr := newTestComponentMgr(t,
+		ComponentConfigModel{ID: "service", ComponentType: string(testMgrServiceType)},
+		ComponentConfigModel{ID: "store", ComponentType: string(testMgrStoreType)},
+	)
+	useTestComponentMgr(t, mgr)
+	appStarted.Store(true)
+	t.Cleanup(func() {
+		appStarted.Store(false)
+		appStopping.Store(false)
+	})
+
+	var stoppingAt time.Time
+	var stoppingEvent APPStoppingEvent
+	var readyWhileStopping bool
+	subKey := t.Name()
+	t.Cleanup(func() {
+		UnsubscribeAll(subKey)
+	})
+	// The stopping and stopped events are retained, skip the ones left by previous runs
+	if _, err := Subscribe(subKey, func(_ context.Context, e APPStoppingEvent) {
+		stoppingAt = time.Now()
+		stoppingEvent = e
+		readyWhileStopping = IsAppReady()
+		recordTestMgrEvent("stopping")
+	}, WithoutReplay()); err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan struct{})
+	if _, err := Subscribe(subKey, func(_ context.Context, e APPStoppedEvent) {
+		recordTestMgrEvent("stopped")
+		close(stopped)
+	}, WithoutReplay()); err != nil {
+		t.Fatal(err)
+	}
+
+	const drainPeriod = 50 * time.Millisecond
+	shutdownApplication(syscall.SIGTERM, drainPeriod, time.Second)
+	stoppedAt := time.Now()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stopped event has not been delivered")
+	}
+
+	checkTestMgrEvents(t, "stopping", "stop service", "stop store", "stopped")
+	if stoppingEvent.Signal != syscall.SIGTERM || stoppingEvent.DrainPeriod != drainPeriod {
+		t.Errorf("got stopping event %+v", stoppingEvent)
+	}
+	if readyWhileStopping {
+		t.Error("the application was ready when the stopping event was published")
+	}
+	if elapsed := stoppedAt.Sub(stoppingAt); elapsed < drainPeriod {
+		t.Errorf("the components stopped %v after the stopping event, want the drain period %v", elapsed, drainPeriod)
+	}
+	if IsAppStarted() {
+		t.Error("the application is still started")
+	}
+}
@@ -5,9 +5,13 @@ go 1.22
 toolchain go1.22.7
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/akley-MK4/go-tools-box v1.0.1
 	github.com/akley-MK4/pubsub v1.0.0
 	github.com/fsnotify/fsnotify v1.7.0
+	github.com/titanous/json5 v1.0.0
+	gopkg.in/ini.v1 v1.67.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.15.0 // indirect